@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ValenTheRed/sudoku/pkg/sudoku"
+)
+
+// collectionPuzzle is a puzzle of a collection along with its save, the
+// progress made on it.
+type collectionPuzzle struct {
+	puzzle sudoku.Grid
+
+	// game is the saved game of the puzzle, or nil if the puzzle is
+	// unplayed or its save couldn't be loaded with err.
+	game *sudoku.Game
+	err  error
+}
+
+// readCollection reads the puzzles of the collection file at path, in
+// any of the formats read by sudoku.ReadPuzzles.
+func readCollection(path string) ([]sudoku.Grid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	puzzles, err := sudoku.ReadPuzzles(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return puzzles, nil
+}
+
+// puzzleKey returns the key the save of puzzle is stored under, a hash
+// of its givens. The same puzzle shares its save between collections.
+func puzzleKey(puzzle sudoku.Grid) string {
+	sum := sha256.Sum256([]byte(puzzle.String()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// puzzlePath returns the path of the file of the save of puzzle.
+func puzzlePath(puzzle sudoku.Grid) string {
+	return filepath.Join(collectionsdir, puzzleKey(puzzle)+".json")
+}
+
+// loadProgress returns the puzzles of a collection along with their
+// saves.
+func loadProgress(puzzles []sudoku.Grid) []collectionPuzzle {
+	progress := make([]collectionPuzzle, len(puzzles))
+	for i, puzzle := range puzzles {
+		progress[i].puzzle = puzzle
+		path := puzzlePath(puzzle)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		progress[i].game, progress[i].err = loadGame(path)
+	}
+	return progress
+}
+
+// describeProgress returns the status of the puzzle of p, unplayed, in
+// progress or solved, along with the time spent on it, on a line.
+func describeProgress(p collectionPuzzle) string {
+	switch {
+	case p.err != nil:
+		return "can't be loaded: " + p.err.Error()
+	case p.game == nil:
+		return fmt.Sprintf("unplayed · %d givens", 81-p.puzzle.Count(0))
+	case p.game.Board.IsSolved():
+		return fmt.Sprintf("solved · %s · %s", p.game.Difficulty, second(p.game.Elapsed))
+	}
+	return "in progress · " + describeGame(p.game)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ValenTheRed/sudoku/pkg/sudoku"
+)
+
+// command is a subcommand of sudoku, run instead of the game by naming
+// it as the first argument.
+type command struct {
+	name string
+
+	// usage describes the command on a line.
+	usage string
+
+	// run runs the command with the arguments following its name.
+	run func(args []string) error
+}
+
+var commands = []command{
+	{"solve", "solve the puzzles read from the standard input", solveCommand},
+	{"generate", "generate puzzles of a difficulty", generateCommand},
+	{"grade", "grade the difficulty of the puzzles read from the standard input", gradeCommand},
+	{"validate", "check that the puzzles read from the standard input have a unique solution", validateCommand},
+	{"export", "export the puzzle of a save slot", exportCommand},
+}
+
+// findCommand returns the command named name, or nil if there is none.
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// usage prints the usage of the game and of the commands.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags]\n       %s command [flags]\n\nFlags of the game:\n", os.Args[0], os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\nCommands, see their flags with -h:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-10s%s\n", c.name, c.usage)
+	}
+}
+
+// formatFlag defines the -format flag of the commands writing puzzles
+// in fs.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", "line", "write the puzzles in `format`: "+strings.Join(sudoku.Formats(), ", "))
+}
+
+// parseFormat returns the Format named name, or an error if there is
+// none.
+func parseFormat(name string) (sudoku.Format, error) {
+	f, ok := sudoku.ParseFormat(name)
+	if !ok {
+		return f, fmt.Errorf("unknown format %q, want one of: %s", name, strings.Join(sudoku.Formats(), ", "))
+	}
+	return f, nil
+}
+
+// writePuzzles writes puzzles to w in format f, separated by an empty
+// line unless one line holds a puzzle.
+func writePuzzles(w io.Writer, puzzles []sudoku.Grid, f sudoku.Format) error {
+	for i, p := range puzzles {
+		if i > 0 && f != sudoku.FormatLine {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := sudoku.WritePuzzle(w, p, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func solveCommand(args []string) error {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	format := formatFlag(fs)
+	fs.Parse(args)
+	f, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	puzzles, err := sudoku.ReadPuzzles(os.Stdin)
+	if err != nil {
+		return err
+	}
+	solutions := make([]sudoku.Grid, len(puzzles))
+	for i, p := range puzzles {
+		var n int
+		if solutions[i], n = sudoku.Solve(p, 1); n == 0 {
+			return fmt.Errorf("puzzle %d has no solution", i+1)
+		}
+	}
+	w := bufio.NewWriter(os.Stdout)
+	if err := writePuzzles(w, solutions, f); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func generateCommand(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	format := formatFlag(fs)
+	difficulty := fs.String("difficulty", "medium", "generate puzzles of `level`: "+strings.ToLower(strings.Join(sudoku.Difficulties(), ", ")))
+	n := fs.Int("n", 1, "generate `count` puzzles")
+	seed := fs.Int64("seed", 0, "generate the puzzles from `seed`, 0 picks a random seed")
+	fs.Parse(args)
+	f, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	d, ok := sudoku.ParseDifficulty(*difficulty)
+	if !ok {
+		return fmt.Errorf("unknown difficulty %q", *difficulty)
+	}
+	if *n < 0 {
+		return fmt.Errorf("invalid count %d", *n)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	// Every puzzle is generated from a seed of its own, so that the
+	// puzzles of a seed don't depend on how many are generated.
+	rng := rand.New(rand.NewSource(*seed))
+	puzzles := make([]sudoku.Grid, *n)
+	for i := range puzzles {
+		puzzles[i], _ = sudoku.GenerateGradedPuzzle(rng.Int63(), d)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	if err := writePuzzles(w, puzzles, f); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func gradeCommand(args []string) error {
+	fs := flag.NewFlagSet("grade", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of grade:\nWrites a line per puzzle: the puzzle, its difficulty, its score and the hardest strategy it needs, separated by tabs.\n")
+	}
+	fs.Parse(args)
+	puzzles, err := sudoku.ReadPuzzles(os.Stdin)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for i, p := range puzzles {
+		if err := sudoku.CheckPuzzle(p); err != nil {
+			return fmt.Errorf("puzzle %d: %w", i+1, err)
+		}
+		gr := sudoku.GradePuzzle(p)
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", p, gr.Difficulty, gr.Score, gr.Hardest)
+	}
+	return w.Flush()
+}
+
+func validateCommand(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of validate:\nWrites a line per puzzle: the puzzle and \"ok\", or what is wrong with it, separated by a tab. Fails if any puzzle is wrong.\n")
+	}
+	fs.Parse(args)
+	puzzles, err := sudoku.ReadPuzzles(os.Stdin)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	invalid := 0
+	for _, p := range puzzles {
+		status := "ok"
+		if err := sudoku.CheckPuzzle(p); err != nil {
+			status = err.Error()
+			invalid++
+		}
+		fmt.Fprintf(w, "%s\t%s\n", p, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d puzzles are invalid", invalid, len(puzzles))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ValenTheRed/sudoku/pkg/sudoku"
+)
+
+// exportWhats names what of a puzzle can be exported: its givens, its
+// givens along with the entries of the user, or its solution.
+var exportWhats = []string{"givens", "state", "solution"}
+
+// exportGrid returns what of the puzzle of b, one of exportWhats.
+func exportGrid(b *sudoku.Board, what string) (sudoku.Grid, error) {
+	switch what {
+	case "givens":
+		return b.Givens(), nil
+	case "state":
+		return b.Digits(), nil
+	case "solution":
+		solution, n := sudoku.Solve(b.Givens(), 2)
+		if n != 1 {
+			return sudoku.Grid{}, errors.New("the puzzle has no unique solution")
+		}
+		return solution, nil
+	}
+	return sudoku.Grid{}, fmt.Errorf("unknown export %q, want one of: %s", what, strings.Join(exportWhats, ", "))
+}
+
+// formatSuffix returns the end of the name of the files exported in
+// format f: its file name extension, preceded by the name of the format
+// if the extension is shared with another format.
+func formatSuffix(f sudoku.Format) string {
+	switch f {
+	case sudoku.FormatSDK:
+		return ".sdk"
+	case sudoku.FormatSS:
+		return ".ss"
+	case sudoku.FormatGrid:
+		return "-grid.txt"
+	}
+	return ".txt"
+}
+
+// exportToFile writes what of the puzzle of b in format f to a file
+// named after slot in the exports directory, and returns its path.
+func exportToFile(b *sudoku.Board, slot, what string, f sudoku.Format) (string, error) {
+	g, err := exportGrid(b, what)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(exportsdir, 0750); err != nil {
+		return "", err
+	}
+	path := filepath.Join(exportsdir, slot+"-"+what+formatSuffix(f))
+	return path, writeFile(path, func(w io.Writer) error {
+		return sudoku.WritePuzzle(w, g, f)
+	})
+}
+
+// exportCommand runs the export subcommand with args, writing a puzzle
+// of a save slot to the standard output.
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	save := fs.String("save", "", "export the puzzle of the save slot `name`, the last played one by default")
+	what := fs.String("what", "givens", "export the `part` of the puzzle: "+strings.Join(exportWhats, ", "))
+	format := formatFlag(fs)
+	fs.Parse(args)
+
+	f, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	if err := setupPaths(); err != nil {
+		return err
+	}
+	if err := migrateSave(); err != nil {
+		return err
+	}
+
+	var game *sudoku.Game
+	if *save != "" {
+		if err := checkSlotName(*save); err != nil {
+			return err
+		}
+		game, err = loadGame(slotPath(*save))
+	} else {
+		var slots []saveSlot
+		if slots, err = listSlots(); err == nil {
+			if len(slots) == 0 {
+				return errors.New("no saved game")
+			}
+			game, err = slots[0].game, slots[0].err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	g, err := exportGrid(game.Board, *what)
+	if err != nil {
+		return err
+	}
+	return sudoku.WritePuzzle(os.Stdout, g, f)
+}
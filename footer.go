@@ -10,8 +10,10 @@ import (
 
 type SudokuFooter struct {
 	*tview.Box
-	frame   *SudokuFrame
-	buttons [10]*tview.Button
+	frame *SudokuFrame
+	// buttons holds the digits 1 to 9, the cross which clears a cell,
+	// and the notes mode toggle, in that order.
+	buttons [11]*tview.Button
 }
 
 func NewSudokuFooter(frame *SudokuFrame) *SudokuFooter {
@@ -20,7 +22,10 @@ func NewSudokuFooter(frame *SudokuFrame) *SudokuFooter {
 		frame: frame,
 	}
 
-	const cross = '✗'
+	const (
+		cross  = '✗'
+		pencil = '✎'
+	)
 
 	f.Box.SetBorderPadding(1, 0, 0, 0)
 
@@ -32,6 +37,11 @@ func NewSudokuFooter(frame *SudokuFrame) *SudokuFooter {
 				char = '0'
 			}
 			g := f.frame.grid
+			if d := int(char - '0'); d != 0 && g.Digits().Count(d) >= 9 {
+				// Every cell of the digit is placed, the button is
+				// disabled.
+				return
+			}
 			// NOTE:
 			// I expected that after setting a different value to the
 			// cell, I would need to manually invoke some some Draw() to
@@ -39,9 +49,7 @@ func NewSudokuFooter(frame *SudokuFrame) *SudokuFooter {
 			// automatically and instaneously, and I've no idea how.
 			// It updates before the timer updates, so timer's
 			// SetChangedFunc() handler can't be the reason.
-			if r, c := g.SelectedCell(); !g.GetCell(r, c).Readonly() {
-				g.SetCellWithUndo(r, c, int(char - '0'))
-			}
+			g.Input(int(char - '0'))
 		})
 		return b
 	}
@@ -50,18 +58,30 @@ func NewSudokuFooter(frame *SudokuFrame) *SudokuFooter {
 		f.buttons[i] = newBtn(rune(i) + '1')
 	}
 	f.buttons[9] = newBtn(cross)
+	f.buttons[10] = tview.NewButton(fmt.Sprintf(" %c ", pencil)).
+		SetSelectedFunc(func() {
+			g := f.frame.grid
+			g.SetNotesMode(!g.NotesMode())
+		})
 
 	return f
 }
 
+// superscripts holds the superscript of every digit from 0 to 9.
+var superscripts = [10]rune{'⁰', '¹', '²', '³', '⁴', '⁵', '⁶', '⁷', '⁸', '⁹'}
+
 // Draw draws f horizontally centered with one cell width gap at the top.
+// The digits and the cross are laid out in two rows of five, and the
+// notes toggle, taking up both rows, to their right. Every digit shows
+// how many of it are left to place as a superscript, and is dimmed once
+// all of it is placed.
 func (f *SudokuFooter) Draw(screen tcell.Screen) {
 	f.SetBackgroundColor(ColorSchemes[Theme]["background"])
 	f.DrawForSubclass(screen, f)
 
 	// assumption: no borders around numbers.
 	const (
-		columns    = 5
+		columns    = 6
 		rows       = 2
 		cellWidth  = 4
 		cellHeight = 2
@@ -74,20 +94,36 @@ func (f *SudokuFooter) Draw(screen tcell.Screen) {
 	sudokuWidth := 9*SudokuGridColumnWidth - 1
 	x = X + (sudokuWidth-width)/2
 
+	digits := f.frame.grid.Digits()
 	for i, button := range f.buttons {
 		button.SetBackgroundColor(ColorSchemes[Theme]["uiSurface"])
 		button.SetBackgroundColorActivated(ColorSchemes[Theme]["foreground"])
 		button.SetLabelColor(ColorSchemes[Theme]["foreground"])
-		button.SetLabelColorActivated(ColorSchemes[Theme][Accent])
-		if i == 5 {
-			y += cellHeight
+		if i < 9 {
+			if left := 9 - digits.Count(i+1); left > 0 {
+				button.SetLabel(fmt.Sprintf(" %c%c", '1'+i, superscripts[left]))
+			} else {
+				button.SetLabel(fmt.Sprintf(" %c ", '1'+i))
+				button.SetLabelColor(ColorSchemes[Theme]["darkerUISurface"])
+			}
 		}
+		button.SetLabelColorActivated(ColorSchemes[Theme][Accent])
 		// I refrenced tview.Grid.Draw() and tview.Flex.Draw() for
 		// writing this Draw() function.
 
 		// This informs the Primitive of it's position so that it
 		// can draw from there.
-		button.SetRect(x+(cellWidth*(i%columns)), y, cellWidth-1, cellHeight-1)
+		switch {
+		case i == 10:
+			if f.frame.grid.NotesMode() {
+				button.SetBackgroundColor(ColorSchemes[Theme][Accent])
+			}
+			button.SetRect(x+(cellWidth*(columns-1)), y, cellWidth-1, rows*cellHeight-1)
+		case i >= 5:
+			button.SetRect(x+(cellWidth*(i-5)), y+cellHeight, cellWidth-1, cellHeight-1)
+		default:
+			button.SetRect(x+(cellWidth*i), y, cellWidth-1, cellHeight-1)
+		}
 		// NOTE: I don't really know why we check for focus and defer
 		// draw, but I assume tview has a good reason for doing this.
 		if button.HasFocus() {
@@ -1,12 +1,10 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
-	"log"
-	"os"
-	"strconv"
+	"time"
 
+	"github.com/ValenTheRed/sudoku/pkg/sudoku"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
@@ -16,125 +14,153 @@ type SudokuFrame struct {
 	timer      *Timer
 	grid       *SudokuGrid
 	numberPad  *SudokuFooter
+
+	// seed is the seed the puzzle was generated from.
+	seed int64
+
+	// level is the difficulty of the puzzle.
+	level sudoku.Difficulty
+
+	// hints is the number of hints used on the puzzle.
+	hints int
+
+	// created is when the game was started.
+	created time.Time
+
+	// autoSolved is true if the puzzle was completed by the solver
+	// instead of the user. The grid is then locked, as it is once the
+	// user completes the puzzle.
+	autoSolved bool
+
+	// completed is called when the user completes the puzzle.
+	completed func()
+
+	// changed is called after every edit of the puzzle.
+	changed func()
 }
 
-func NewSudokuFrame() *SudokuFrame {
-	f := &SudokuFrame{
-		Grid: tview.NewGrid(),
-		grid: GeneratePuzzle(),
+// NewSudokuFrame returns a SudokuFrame holding a new puzzle of
+// difficulty d generated from seed.
+func NewSudokuFrame(seed int64, d sudoku.Difficulty) *SudokuFrame {
+	return NewSudokuFrameFromGame(newGame(seed, d))
+}
+
+// newGame returns a new game of difficulty d generated from seed.
+func newGame(seed int64, d sudoku.Difficulty) *sudoku.Game {
+	puzzle, gr := sudoku.GenerateGradedPuzzle(seed, d)
+	return &sudoku.Game{
+		Board:      sudoku.NewBoard(puzzle),
+		Difficulty: gr.Difficulty,
+		Seed:       seed,
+		Created:    time.Now(),
 	}
-	f.difficulty = NewSudokuHeader(f)
-	f.timer = NewTimer(f)
-	f.numberPad = NewSudokuFooter(f)
-	f.difficulty.SetText("Hard")
+}
 
-	f.SetRows(0, 9*SudokuGridRowHeight-1, 0).SetColumns(0, 0)
-	f.
-		AddItem(f.timer, 0, 1, 1, 1, 0, 0, false).
-		AddItem(f.difficulty, 0, 0, 1, 1, 0, 0, false)
+// newGameFromPuzzle returns a new game of puzzle, graded to find its
+// difficulty.
+func newGameFromPuzzle(puzzle sudoku.Grid) *sudoku.Game {
+	return &sudoku.Game{
+		Board:      sudoku.NewBoard(puzzle),
+		Difficulty: sudoku.GradePuzzle(puzzle).Difficulty,
+		Created:    time.Now(),
+	}
+}
+
+// sudokuFrameMargin is the number of rows needed by the header and the
+// footer of a SudokuFrame.
+const sudokuFrameMargin = 10
+
+// Draw draws f. The grid is given the room for the larger layout with
+// notes if f is tall enough.
+func (f *SudokuFrame) Draw(screen tcell.Screen) {
+	_, _, _, height := f.GetRect()
+	rh := SudokuGridRowHeight
+	if height >= 9*SudokuGridNotesRowHeight-1+sudokuFrameMargin {
+		rh = SudokuGridNotesRowHeight
+	}
+	f.SetRows(0, 9*rh-1, 0)
+	f.Grid.Draw(screen)
+}
+
+// NewGame replaces the puzzle of f with a new puzzle of difficulty d
+// generated from seed, and resets the timer.
+func (f *SudokuFrame) NewGame(seed int64, d sudoku.Difficulty) *SudokuFrame {
+	f.SetGame(newGame(seed, d))
+	f.timer.Start()
+	return f
+}
+
+// SetGame replaces the game played in f with game. The timer is stopped
+// and set to the time spent on game, and the grid is locked if game is
+// already solved.
+func (f *SudokuFrame) SetGame(game *sudoku.Game) *SudokuFrame {
+	f.RemoveItem(f.grid)
+	f.grid = NewSudokuGrid(game.Board)
 	f.AddItem(f.grid, 1, 0, 1, 2, 0, 0, true)
-	f.AddItem(f.numberPad, 2, 0, 1, 2, 0, 0, false)
+	f.grid.SetCompletedFunc(f.completed)
+	f.grid.SetChangedFunc(f.changed)
+	f.grid.SetLocked(f.grid.IsSolved())
+	f.seed = game.Seed
+	f.level = game.Difficulty
+	f.hints = game.Hints
+	f.created = game.Created
+	f.autoSolved = false
+	f.difficulty.SetText(game.Difficulty.String())
+	f.timer.Stop()
+	f.timer.SetElapsed(game.Elapsed)
+	return f
+}
+
+// SetCompletedFunc sets the handler which is called when the puzzle is
+// correctly completed, also for the puzzles of new games.
+func (f *SudokuFrame) SetCompletedFunc(handler func()) *SudokuFrame {
+	f.completed = handler
+	f.grid.SetCompletedFunc(handler)
 	return f
 }
 
-// NewSudokuFrameFromFile returns an initialised SudokuFrame from
-// savefile, with undofile used to restore the undo history.
-func NewSudokuFrameFromFile(savefile, undofile *os.File) *SudokuFrame {
+// SetChangedFunc sets the handler which is called after every edit of
+// the puzzle, also for the puzzles of new games.
+func (f *SudokuFrame) SetChangedFunc(handler func()) *SudokuFrame {
+	f.changed = handler
+	f.grid.SetChangedFunc(handler)
+	return f
+}
+
+// NewSudokuFrameFromGame returns a SudokuFrame holding game.
+func NewSudokuFrameFromGame(game *sudoku.Game) *SudokuFrame {
 	f := &SudokuFrame{
 		Grid: tview.NewGrid(),
-		grid: NewSudokuGrid(),
 	}
 	f.difficulty = NewSudokuHeader(f)
 	f.timer = NewTimer(f)
 	f.numberPad = NewSudokuFooter(f)
 
-	scan := bufio.NewScanner(savefile)
-
-	// puzzle
-	if !scan.Scan() {
-		log.Fatalln("NewSudokuFrameFromFile: parsing savefile: no puzzle text")
-	}
-	bytes := scan.Bytes()
-	if l := len(bytes); l < 81 || l > 2*81 {
-		log.Fatalf("NewSudokuFrameFromFile: parsing puzzle \"%s\": invalid length: have %d, want 81 <= length <= 162", bytes, l)
-	}
-	for i, j := 0, 0; i < len(bytes); i++ {
-		if b := bytes[i]; b != '_' && b != '.' && !(b >= '0' && b <= '9') {
-			log.Fatalln("NewSudokuFrameFromFile: parsing puzzle: character must be in the set [_.1-9]")
-		}
-		r, c := j/9, j%9
-		if bytes[i] == '_' {
-			f.grid.GetCell(r, c).SetReadonly(true)
-			continue
-		}
-		v := 0
-		if bytes[i] != '.' {
-			v = int(bytes[i] - '0')
-		}
-		f.grid.SetCellWithoutUndo(r, c, v)
-		j++
-	}
-
-	// time
-	if !scan.Scan() {
-		log.Fatalln("NewSudokuFrameFromFile: parsing savefile: no elapsed time")
-	}
-
-	if t, err := strconv.Atoi(scan.Text()); err != nil {
-		log.Fatalln("NewSudokuFrameFromFile: parsing elapsed time:", err)
-	} else {
-		f.timer.SetElapsed(t)
-	}
-
-	// difficulty
-	if !scan.Scan() {
-		log.Fatalln("NewSudokuFrameFromFile: parsing savefile: no difficulty text")
-	}
-	switch t := scan.Text(); t {
-	case "Easy", "Medium", "Hard":
-		f.difficulty.SetText(scan.Text())
-	default:
-		log.Fatalln("NewSudokuFrameFromFile: parsing difficulty: difficulty must be either one of: Easy, Medium, Hard")
-	}
-
-	f.grid.ReadUndoHistoryFromFile(undofile)
-
 	f.SetRows(0, 9*SudokuGridRowHeight-1, 0).SetColumns(0, 0)
 	f.
 		AddItem(f.timer, 0, 1, 1, 1, 0, 0, false).
 		AddItem(f.difficulty, 0, 0, 1, 1, 0, 0, false)
-	f.AddItem(f.grid, 1, 0, 1, 2, 0, 0, true)
 	f.AddItem(f.numberPad, 2, 0, 1, 2, 0, 0, false)
+	f.SetGame(game)
 	return f
 }
 
-// SavePuzzleToFile saves puzzle, puzzle time, and puzzle difficulty to
-// file, in that order. It also saves the undo history.
-// NOTE: It uses '.' to denote empty cell.
-// NOTE: It appends '_' in front of readonly cells
-func (f *SudokuFrame) SavePuzzleToFile(savefile, undofile *os.File) {
-	g := f.grid
-	for r := 0; r < 9; r++ {
-		var s []byte
-		for c := 0; c < 9; c++ {
-			cell := g.GetCell(r, c)
-			var v byte
-			if digit := cell.Value(); digit == 0 {
-				v = '.'
-			} else {
-				v = byte(digit) + '0'
-			}
-			if cell.Readonly() {
-				s = append(s, '_', v)
-			} else {
-				s = append(s, v)
-			}
-		}
-		savefile.Write(s)
+// Game returns the game played in f, as it would be saved now.
+func (f *SudokuFrame) Game() *sudoku.Game {
+	return &sudoku.Game{
+		Board:      f.grid.Board,
+		Elapsed:    int(f.timer.Elapsed()),
+		Difficulty: f.level,
+		Seed:       f.seed,
+		Hints:      f.hints,
+		Created:    f.created,
+		Saved:      time.Now(),
 	}
-	savefile.Write([]byte{'\n'})
-	fmt.Fprintln(savefile, int(f.timer.elapsed))
-	fmt.Fprintln(savefile, f.difficulty.GetText(true))
+}
 
-	g.FlushUndoHistoryToFile(undofile)
+// SavePuzzleToFile saves the game played in f to the file at path,
+// atomically, so that a failed save leaves the previous one intact. See
+// sudoku.Game.Write for the format.
+func (f *SudokuFrame) SavePuzzleToFile(path string) error {
+	return writeFile(path, f.Game().Write)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -85,14 +86,19 @@ func (h *SudokuHeader) MouseHandler() func(tview.MouseAction, *tcell.EventMouse,
 
 // Timer counts the number of seconds it took to complete the puzzle.
 //
-// I've not included any way to check whether the Timer is running or
-// not since, Timer will start at the beginning of the application and
-// stop only when the puzzle is correctly completed or the application
-// exits.
+// Timer is stopped when the puzzle is completed, or paused, and starts
+// again from where it stopped. Calling Start or Stop more than once is
+// harmless.
 type Timer struct {
 	*SudokuHeader
+
+	// mu guards elapsed, which is incremented by the worker goroutine.
+	mu      sync.Mutex
 	elapsed second
-	stopCh  chan struct{}
+
+	// stopCh is closed to stop the worker of a running Timer. It is nil
+	// while the Timer is stopped.
+	stopCh chan struct{}
 }
 
 // NewTimer returns a new initialised Timer. 'frame' must be the
@@ -108,7 +114,6 @@ type Timer struct {
 func NewTimer(frame *SudokuFrame) *Timer {
 	t := &Timer{
 		SudokuHeader: NewSudokuHeader(frame).SetTextAlign(tview.AlignRight),
-		stopCh:       make(chan struct{}),
 	}
 	t.SetText(t.elapsed.String())
 	return t
@@ -116,20 +121,46 @@ func NewTimer(frame *SudokuFrame) *Timer {
 
 // SetElapsed sets the time elapsed for the timer to sec.
 func (t *Timer) SetElapsed(sec int) *Timer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.elapsed = second(sec)
 	t.SetText(t.elapsed.String())
 	return t
 }
 
+// Elapsed returns the time elapsed for the timer.
+func (t *Timer) Elapsed() second {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.elapsed
+}
+
+// Running reports whether the timer is counting.
+func (t *Timer) Running() bool {
+	return t.stopCh != nil
+}
+
+// Start starts counting from the time elapsed so far.
 func (t *Timer) Start() {
+	if t.Running() {
+		return
+	}
+	t.stopCh = make(chan struct{})
 	go worker(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
 		t.elapsed++
 		t.SetText(t.elapsed.String())
 	}, t.stopCh)
 }
 
+// Stop stops counting, without waiting for the worker to return.
 func (t *Timer) Stop() {
-	t.stopCh <- struct{}{}
+	if !t.Running() {
+		return
+	}
+	close(t.stopCh)
+	t.stopCh = nil
 }
 
 type second int
@@ -159,6 +190,12 @@ func worker(work func(), quit <-chan struct{}) {
 	for {
 		select {
 		case <-t.C:
+			// Both channels may be ready, don't count past a quit.
+			select {
+			case <-quit:
+				return
+			default:
+			}
 			work()
 		case <-quit:
 			return
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ListModal is a centered window letting the user pick one of a list of
+// items, each with a line of details below it. Like Modal, it is meant to
+// be shown on top of the game.
+type ListModal struct {
+	*tview.Box
+
+	// The frame embedded in the modal.
+	frame *tview.Frame
+
+	// The list embedded in the modal's frame.
+	list *tview.List
+
+	// The message text shown above the list.
+	text string
+
+	// The text color.
+	textColor tcell.Color
+
+	// The optional callback for when the user selected one of the items.
+	// It receives the index of the item, or -1 if the user pressed the
+	// Escape key.
+	done func(index int)
+}
+
+// NewListModal returns a new list window.
+func NewListModal() *ListModal {
+	m := &ListModal{
+		Box:       tview.NewBox(),
+		textColor: tview.Styles.PrimaryTextColor,
+	}
+	m.list = tview.NewList().
+		SetHighlightFullLine(true).
+		SetWrapAround(false).
+		SetSelectedFunc(func(index int, _, _ string, _ rune) {
+			if m.done != nil {
+				m.done(index)
+			}
+		}).
+		SetDoneFunc(func() {
+			if m.done != nil {
+				m.done(-1)
+			}
+		})
+	m.frame = tview.NewFrame(m.list).SetBorders(0, 0, 1, 0, 0, 0)
+	m.frame.SetBorder(true).
+		SetBorderPadding(1, 1, 1, 1)
+	return m
+}
+
+// SetBorderColor sets the color of the modal frame border.
+func (m *ListModal) SetBorderColor(color tcell.Color) *ListModal {
+	m.frame.SetBorderColor(color)
+	return m
+}
+
+// SetBackgroundColor sets the color of the modal frame background.
+func (m *ListModal) SetBackgroundColor(color tcell.Color) *ListModal {
+	m.list.SetBackgroundColor(color)
+	m.frame.SetBackgroundColor(color)
+	return m
+}
+
+// SetTextColor sets the color of the message text and of the items.
+func (m *ListModal) SetTextColor(color tcell.Color) *ListModal {
+	m.textColor = color
+	m.list.SetMainTextColor(color)
+	return m
+}
+
+// SetDetailsColor sets the color of the details of the items.
+func (m *ListModal) SetDetailsColor(color tcell.Color) *ListModal {
+	m.list.SetSecondaryTextColor(color)
+	return m
+}
+
+// SetSelectedColors sets the text and background color of the item
+// under the cursor.
+func (m *ListModal) SetSelectedColors(text, background tcell.Color) *ListModal {
+	m.list.SetSelectedTextColor(text).SetSelectedBackgroundColor(background)
+	return m
+}
+
+// SetDoneFunc sets a handler which is called when the user selected one
+// of the items. It receives the index of the item. The handler is also
+// called when the user presses the Escape key, the index is then -1.
+func (m *ListModal) SetDoneFunc(handler func(index int)) *ListModal {
+	m.done = handler
+	return m
+}
+
+// SetText sets the message text shown above the list.
+func (m *ListModal) SetText(text string) *ListModal {
+	m.text = text
+	return m
+}
+
+// AddItem adds an item, with a line of details below it, to the end of
+// the list.
+func (m *ListModal) AddItem(text, details string) *ListModal {
+	m.list.AddItem(text, details, 0, nil)
+	return m
+}
+
+// SetCurrentItem moves the cursor to the item with the given index.
+func (m *ListModal) SetCurrentItem(index int) *ListModal {
+	m.list.SetCurrentItem(index)
+	return m
+}
+
+// Clear removes all items from the list.
+func (m *ListModal) Clear() *ListModal {
+	m.list.Clear()
+	return m
+}
+
+// Focus is called when this primitive receives focus.
+func (m *ListModal) Focus(delegate func(p tview.Primitive)) {
+	delegate(m.list)
+}
+
+// HasFocus returns whether or not this primitive has focus.
+func (m *ListModal) HasFocus() bool {
+	return m.list.HasFocus()
+}
+
+// Draw draws this primitive onto the screen. The window is wide enough
+// for the longest item, and as tall as the list, within the screen.
+func (m *ListModal) Draw(screen tcell.Screen) {
+	screenWidth, screenHeight := screen.Size()
+	width := screenWidth / 3
+	for i := 0; i < m.list.GetItemCount(); i++ {
+		text, details := m.list.GetItemText(i)
+		for _, s := range []string{text, details} {
+			if w := tview.TaggedStringWidth(s) + 2; w > width {
+				width = w
+			}
+		}
+	}
+	if width > screenWidth-4 {
+		width = screenWidth - 4
+	}
+
+	m.frame.Clear()
+	var lines []string
+	for _, line := range strings.Split(m.text, "\n") {
+		if len(line) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, tview.WordWrap(line, width)...)
+	}
+	for _, line := range lines {
+		m.frame.AddText(line, true, tview.AlignCenter, m.textColor)
+	}
+
+	// Set the modal's position and size.
+	height := len(lines) + 2*m.list.GetItemCount() + 5
+	if height > screenHeight-2 {
+		height = screenHeight - 2
+	}
+	width += 4
+	x := (screenWidth - width) / 2
+	y := (screenHeight - height) / 2
+	m.SetRect(x, y, width, height)
+
+	// Draw the frame.
+	m.frame.SetRect(x, y, width, height)
+	m.frame.Draw(screen)
+}
+
+// MouseHandler returns the mouse handler for this primitive.
+func (m *ListModal) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return m.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		// Pass mouse events on to the list.
+		consumed, capture = m.list.MouseHandler()(action, event, setFocus)
+		if !consumed && action == tview.MouseLeftDown && m.InRect(event.Position()) {
+			setFocus(m)
+			consumed = true
+		}
+		return
+	})
+}
+
+// InputHandler returns the handler for this primitive.
+func (m *ListModal) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return m.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if m.frame.HasFocus() {
+			if handler := m.frame.InputHandler(); handler != nil {
+				handler(event, setFocus)
+				return
+			}
+		}
+	})
+}
@@ -2,77 +2,227 @@ package main
 
 import (
 	"container/ring"
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/ValenTheRed/sudoku/pkg/sudoku"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
 var (
-	// undopath stores the path to the undofile
-	undopath string
+	// savesdir stores the path of the directory holding the save
+	// slots, one file per slot.
+	savesdir string
 
-	// savepath stores the path of the file where puzzle information
-	// will be stored for continu-ing purposes.
-	savepath string
+	// collectionsdir stores the path of the directory holding the
+	// saves of the puzzles of collections.
+	collectionsdir string
+
+	// exportsdir stores the path of the directory puzzles are exported
+	// to from the game.
+	exportsdir string
+
+	// singleSavepath stores the path of the only save of versions
+	// before save slots. It is moved to a save slot on startup.
+	singleSavepath string
+
+	// legacySavepath, undopath and redopath store the paths of the
+	// files older versions saved the game in. They are moved to a save
+	// slot on startup, unless they can't be loaded.
+	legacySavepath, undopath, redopath string
+
+	// settingspath stores the path of the settings file.
+	settingspath string
 
 	// continueFlag set to true will restore the puzzle from the
 	// previous session.
 	continueFlag bool
+
+	// saveFlag is the name of the save slot to play in. Its game is
+	// continued if it has one.
+	saveFlag string
+
+	// seedFlag is the seed the new puzzle is generated from. 0 picks a
+	// random seed.
+	seedFlag int64
+
+	// difficultyFlag is the difficulty of the new puzzle.
+	difficultyFlag string
+
+	// puzzleFlag is the puzzle to play, in any of the formats read by
+	// sudoku.ReadPuzzles.
+	puzzleFlag string
+
+	// fileFlag is the path of a file holding the puzzle to play, in any
+	// of the formats read by sudoku.ReadPuzzles.
+	fileFlag string
+
+	// collectionFlag is the path of a file holding a collection of
+	// puzzles, in any of the formats read by sudoku.ReadPuzzles.
+	collectionFlag string
+
+	// autosaveFlag is the number of seconds between autosaves. 0
+	// disables autosaving, except after batches of edits.
+	autosaveFlag int
 )
 
+// autosaveEdits is the number of edits after which the game is
+// autosaved.
+const autosaveEdits = 10
+
 func init() {
 	flag.BoolVar(&continueFlag, "continue", false, "restore previous sesssions puzzle")
 	flag.BoolVar(&continueFlag, "c", false, "restore previous sesssions puzzle")
+	flag.StringVar(&saveFlag, "save", "", "play in the save slot `name`, continuing its puzzle if it has one")
+	flag.StringVar(&difficultyFlag, "difficulty", "medium", "generate a puzzle of `level`: easy, medium, hard, expert or evil")
+	flag.Int64Var(&seedFlag, "seed", 0, "generate the puzzle from `seed`, 0 picks a random seed")
+	flag.StringVar(&puzzleFlag, "puzzle", "", "play `puzzle`, given as a line of 81 cells with '.' or '0' denoting an empty cell")
+	flag.StringVar(&fileFlag, "file", "", "play the first puzzle of the file at `path`, an .sdk, .ss, .sdm or text file of 81 cell lines")
+	flag.StringVar(&collectionFlag, "collection", "", "choose the puzzle to play from the collection in the file at `path`")
+	flag.IntVar(&autosaveFlag, "autosave", 30, "autosave the game every `seconds`, 0 disables it")
+}
 
-	// set undopath to the path of the undo file
-
+// setupPaths sets the paths of the files of the game, under
+// $XDG_DATA_HOME/sudoku, and creates that directory if needed.
+func setupPaths() error {
 	localshare, exists := os.LookupEnv("XDG_DATA_HOME")
 	if !exists {
 		home, err := os.UserHomeDir()
 		if err != nil {
-			log.Fatalln(err)
+			return err
 		}
 		localshare = path.Join(home, `.local/share/sudoku`)
 	} else {
 		localshare = path.Join(localshare, `sudoku`)
 	}
 
+	savesdir = path.Join(localshare, `saves`)
+	exportsdir = path.Join(localshare, `exports`)
+	collectionsdir = path.Join(localshare, `collections`)
+	singleSavepath = path.Join(localshare, `save.json`)
+	legacySavepath = path.Join(localshare, `save`)
 	undopath = path.Join(localshare, `undo`)
-	savepath = path.Join(localshare, `save`)
+	redopath = path.Join(localshare, `redo`)
+	settingspath = path.Join(localshare, `settings`)
 
-	if err := os.MkdirAll(localshare, 0750); err != nil {
-		log.Fatalln(err)
+	if err := os.MkdirAll(savesdir, 0750); err != nil {
+		return err
 	}
+	return os.MkdirAll(collectionsdir, 0750)
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if c := findCommand(os.Args[1]); c != nil {
+			log.SetFlags(0)
+			log.SetPrefix("sudoku " + c.name + ": ")
+			if err := c.run(os.Args[2:]); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
+	}
+	flag.Usage = usage
 	flag.Parse()
 
+	if err := setupPaths(); err != nil {
+		log.Fatalln(err)
+	}
+	if err := migrateSave(); err != nil {
+		log.Fatalln(err)
+	}
+	if saveFlag != "" {
+		if err := checkSlotName(saveFlag); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
 	SetTheme("dark", "purple")
 
+	// The settings file is missing until the settings are first saved.
+	// settingsErr is the error the settings file couldn't be read with.
+	// The default settings are used instead, and the file is rewritten
+	// with them on the next save.
+	var settingsErr error
+	if settingsfile, err := os.Open(settingspath); err == nil {
+		s := settings
+		if settingsErr = s.ReadSettings(settingsfile); settingsErr == nil {
+			settings = s
+		}
+		settingsfile.Close()
+	} else if !os.IsNotExist(err) {
+		settingsErr = err
+	}
+
 	app := tview.NewApplication().EnableMouse(true)
 
-	var frame *SudokuFrame
-	if continueFlag {
-		savefile, err := os.Open(savepath)
-		if err != nil {
+	d, ok := sudoku.ParseDifficulty(difficultyFlag)
+	if !ok {
+		log.Fatalln("unknown difficulty:", difficultyFlag)
+	}
+	seed := seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	// slot is the save slot the game is played in, or "" while playing
+	// a puzzle of the collection, and savepath the path of its save.
+	slot := saveFlag
+	var game *sudoku.Game
+	// loadErr is the error the saved game couldn't be loaded with. A new
+	// game is played instead, and the user is asked how to recover.
+	var loadErr error
+	imported, err := importPuzzle()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// collection holds the puzzles of the collection, if one is given.
+	var collection []sudoku.Grid
+	if collectionFlag != "" {
+		if collection, err = readCollection(collectionFlag); err != nil {
 			log.Fatalln(err)
 		}
-		undofile, err := os.Open(undopath)
+	}
+	switch {
+	case imported != nil:
+		game = imported
+	case slot != "":
+		if slotExists(slot) {
+			game, loadErr = loadGame(slotPath(slot))
+		}
+	case continueFlag:
+		slots, err := listSlots()
 		if err != nil {
 			log.Fatalln(err)
 		}
-		frame = NewSudokuFrameFromFile(savefile, undofile)
+		if len(slots) > 0 {
+			slot = slots[0].name
+			game, loadErr = slots[0].game, slots[0].err
+		} else if _, err := os.Stat(legacySavepath); !os.IsNotExist(err) {
+			game, loadErr = loadLegacyGame()
+		}
+	}
+	if slot == "" {
+		slot = newSlotName()
+	}
+	savepath := slotPath(slot)
 
-		savefile.Close()
-		undofile.Close()
+	var frame *SudokuFrame
+	if game != nil {
+		frame = NewSudokuFrameFromGame(game)
 	} else {
-		frame = NewSudokuFrame()
+		frame = NewSudokuFrame(seed, d)
 	}
 	frame.timer.SetChangedFunc(func() {
 		app.Draw()
@@ -86,6 +236,12 @@ func main() {
 	solveModal.SetText("Do you want to solve this game?")
 	solveModal.AddButtons([]string{"Cancel", "Yes"})
 
+	// Shown while the puzzle is being solved
+	solvingModal := NewModal()
+	InitModalStyle(solvingModal)
+	solvingModal.SetText("Solving the puzzle...")
+	solvingModal.AddButtons([]string{"Cancel"})
+
 	// Restart this game
 	resetModal := NewModal()
 	InitModalStyle(resetModal)
@@ -99,6 +255,28 @@ func main() {
 	validateModal.AddButtons([]string{"Cancel", "Yes"})
 	validateModal.SetFocus(1)
 
+	// Start a new game
+	newGameModal := NewModal()
+	InitModalStyle(newGameModal)
+	newGameModal.SetText("Choose the difficulty of the new game")
+	newGameModal.AddButtons(append(sudoku.Difficulties(), "Cancel"))
+	newGameModal.SetFocus(int(sudoku.Medium))
+
+	// Shown once the puzzle is completed
+	victoryModal := NewModal()
+	InitModalStyle(victoryModal)
+	victoryModal.AddButtons([]string{"New game", "Quit"})
+
+	// Informs the user of the outcome of an action
+	messageModal := NewModal()
+	InitModalStyle(messageModal)
+	messageModal.AddButtons([]string{"Ok"})
+
+	// Explains the hint highlighted on the grid
+	hintModal := NewModal()
+	InitModalStyle(hintModal)
+	hintModal.AddButtons([]string{"Ok", "Apply"})
+
 	accentModal := NewModal()
 	InitModalStyle(accentModal)
 	accentModal.SetText("Choose color")
@@ -106,14 +284,63 @@ func main() {
 		"Cyan", "Purple", "Pink", "Red", "Orange", "Yellow", "Green",
 	})
 
+	settingsModal := NewModal()
+	InitModalStyle(settingsModal)
+	settingsModal.SetText("Settings")
+	var settingsLabels []string
+	for _, t := range settings.toggles() {
+		settingsLabels = append(settingsLabels, toggleLabel(t))
+	}
+	settingsModal.AddButtons(append(settingsLabels, "Done"))
+
+	// Choose the save slot to play in
+	loadModal := NewListModal()
+	InitListModalStyle(loadModal)
+	loadModal.SetText("Choose the game to load")
+
+	// Choose the puzzle of the collection to play
+	collectionModal := NewListModal()
+	InitListModalStyle(collectionModal)
+	collectionModal.SetText("Choose a puzzle of the collection")
+
+	// Export the puzzle, choosing what of it and then the format
+	exportModal := NewModal()
+	InitModalStyle(exportModal)
+	exportModal.SetText("What do you want to export?")
+	exportModal.AddButtons([]string{"Givens", "State", "Solution", "Cancel"})
+	exportFormatModal := NewModal()
+	InitModalStyle(exportFormatModal)
+	exportFormatModal.SetText("Choose the format to export in")
+	exportFormatModal.AddButtons(append(sudoku.Formats(), "Cancel"))
+
+	// Shown when the game couldn't be saved on quitting
+	saveErrorModal := NewModal()
+	InitModalStyle(saveErrorModal)
+	saveErrorModal.AddButtons([]string{"Retry", "Cancel", "Quit"})
+
+	// Shown when the saved game couldn't be loaded
+	recoveryModal := NewModal()
+	InitModalStyle(recoveryModal)
+	recoveryModal.AddButtons([]string{"New game", "Open backup", "Quit"})
+
 	helpModal := NewModal()
 	InitModalStyle(helpModal)
 	helpModal.SetText(`Shortcut keys
+n  New game
+L  Load game
+e  Export
+o  Open a puzzle of the collection
 u  Undo
+U  Redo (also Ctrl-R)
+H  Hint, press again to apply it
+m  Toggle notes mode
 v  Validate
 s  Solve
+r  Reset grid
+p  Pause/Resume
 t  Switch Theme
 c  Change Accent
+,  Settings
 ?/h  Help window
 `)
 	helpModal.AddButtons([]string{"Ok"})
@@ -122,10 +349,22 @@ c  Change Accent
 		go func() {
 			SetTheme(t, accent)
 			InitSidepaneStyle(sidepane)
+			InitModalStyle(newGameModal)
 			InitModalStyle(solveModal)
 			InitModalStyle(resetModal)
 			InitModalStyle(validateModal)
+			InitModalStyle(messageModal)
+			InitModalStyle(victoryModal)
+			InitModalStyle(hintModal)
 			InitModalStyle(accentModal)
+			InitModalStyle(settingsModal)
+			InitModalStyle(recoveryModal)
+			InitModalStyle(saveErrorModal)
+			InitListModalStyle(loadModal)
+			InitListModalStyle(collectionModal)
+			InitModalStyle(exportModal)
+			InitModalStyle(solvingModal)
+			InitModalStyle(exportFormatModal)
 			InitModalStyle(helpModal)
 			app.Draw()
 		}()
@@ -139,7 +378,7 @@ c  Change Accent
 		setAppThemeAccent(t, Accent)
 	}
 	// Theme changer
-	sidepane.GetButton(4).SetSelectedFunc(switchAppTheme)
+	sidepane.GetButton(themeButton).SetSelectedFunc(switchAppTheme)
 
 	grid := tview.NewGrid()
 	grid.SetRows(0).SetColumns(-1, -3).
@@ -148,15 +387,363 @@ c  Change Accent
 
 	pages := tview.NewPages()
 	pages.AddPage("grid", grid, true, true)
+	pages.AddPage("newgame", newGameModal, true, false)
 	pages.AddPage("reset", resetModal, true, false)
 	pages.AddPage("solve", solveModal, true, false)
 	pages.AddPage("validate", validateModal, true, false)
+	pages.AddPage("message", messageModal, true, false)
+	pages.AddPage("victory", victoryModal, true, false)
+	pages.AddPage("hint", hintModal, true, false)
 	pages.AddPage("accent", accentModal, true, false)
+	pages.AddPage("settings", settingsModal, true, false)
+	pages.AddPage("recovery", recoveryModal, true, false)
+	pages.AddPage("saveerror", saveErrorModal, true, false)
+	pages.AddPage("load", loadModal, true, false)
+	pages.AddPage("collection", collectionModal, true, false)
+	pages.AddPage("export", exportModal, true, false)
+	pages.AddPage("solving", solvingModal, true, false)
+	pages.AddPage("exportformat", exportFormatModal, true, false)
 	pages.AddPage("help", helpModal, true, false)
-	sidepane.GetButton(0).SetSelectedFunc(func() {
+
+	// userPaused is true while the user has paused the game. The game
+	// is also paused while a confirmation modal is showing.
+	userPaused := false
+	// setPaused hides the grid and stops the clock if v is true, or
+	// shows the grid and starts the clock, unless the game is over.
+	setPaused := func(v bool) {
+		frame.grid.SetPaused(v)
+		if v || frame.grid.Locked() {
+			frame.timer.Stop()
+		} else {
+			frame.timer.Start()
+		}
+	}
+	setUserPaused := func(v bool) {
+		userPaused = v
+		setPaused(v)
+		label := "Pause"
+		if v {
+			label = "Resume"
+		}
+		sidepane.GetButton(pauseButton).SetText(label)
+	}
+	togglePause := func() {
+		if !frame.grid.Locked() {
+			setUserPaused(!userPaused)
+		}
+	}
+	// showConfirm shows the confirmation modal in page name, pausing the
+	// game while it is showing.
+	showConfirm := func(name string) {
+		pages.ShowPage(name)
+		setPaused(true)
+	}
+	// recovering is set while the user has yet to choose how to recover
+	// from a save which couldn't be loaded. The game isn't saved in the
+	// meantime.
+	recovering := loadErr != nil
+	// closeConfirm hides the confirmation modal, resuming the game
+	// unless the user has paused it. While recovering, the recovery
+	// modal is shown again instead, so that the game is never played
+	// without being saved.
+	closeConfirm := func() {
+		pages.SwitchToPage("grid")
+		if recovering {
+			showConfirm("recovery")
+			return
+		}
+		setPaused(userPaused)
+	}
+	// leaveRecovery ends recovering, once the user chose to play
+	// another game than the one which couldn't be loaded.
+	leaveRecovery := func() {
+		recovering = false
+		pages.HidePage("recovery")
+	}
+	showMessage := func(text string) {
+		messageModal.SetText(text)
+		// Pages are drawn in the order they were added, bring the
+		// message above the modal it may be shown on top of.
+		pages.ShowPage("message").SendToFront("message")
+	}
+	// The message may be shown on top of another modal, which is shown
+	// again once the message is closed.
+	messageModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		pages.HidePage("message")
+	})
+	// quitWithoutSaving is set when the user quits leaving the save as
+	// it is, and saved once the game is saved on quitting.
+	quitWithoutSaving, saved := false, false
+	// backedUp is set once the save found in the slot is kept as a
+	// backup. A save which couldn't be loaded isn't, so that it never
+	// pushes a good backup out.
+	backedUp := loadErr != nil
+	// useSlot switches to playing in save slot name.
+	useSlot := func(name string) {
+		slot, savepath = name, slotPath(name)
+		backedUp = false
+	}
+	// usePuzzle switches to playing puzzle of the collection, saved
+	// apart from the save slots.
+	usePuzzle := func(puzzle sudoku.Grid) {
+		slot, savepath = "", puzzlePath(puzzle)
+		backedUp = false
+	}
+	// save saves the game and the settings. A save which couldn't be
+	// loaded is left as it is until the user chooses how to recover.
+	save := func() error {
+		if recovering {
+			return nil
+		}
+		if !backedUp {
+			if err := rotateBackups(savepath, saveBackups); err != nil {
+				return err
+			}
+		}
+		backedUp = true
+		if err := frame.SavePuzzleToFile(savepath); err != nil {
+			return err
+		}
+		return writeFile(settingspath, settings.WriteSettings)
+	}
+	// quit saves the game and stops the application. If the game can't
+	// be saved, the user is asked what to do instead.
+	quit := func() {
+		if err := save(); err != nil {
+			saveErrorModal.SetText(fmt.Sprintf("The game couldn't be saved, the previous save is kept.\n\n%v\n\nDo you want to retry, go back to the game, or quit without saving?", err))
+			showConfirm("saveerror")
+			return
+		}
+		saved = true
+		app.Stop()
+	}
+	saveErrorModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		closeConfirm()
+		saveErrorModal.SetFocus(0)
+		switch buttonLabel {
+		case "Retry":
+			quit()
+		case "Quit":
+			quitWithoutSaving = true
+			app.Stop()
+		}
+	})
+	recoveryModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		switch buttonLabel {
+		case "New game":
+			// Leave the save which couldn't be loaded in its slot.
+			leaveRecovery()
+			useSlot(newSlotName())
+			closeConfirm()
+		case "Open backup":
+			// Open the newest backup that loads.
+			var game *sudoku.Game
+			err := errors.New("there is no backup")
+			for i := 1; i <= saveBackups && game == nil; i++ {
+				path := backupPath(savepath, i)
+				if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+					continue
+				}
+				game, err = loadGame(path)
+			}
+			if game == nil {
+				recoveryModal.SetText(fmt.Sprintf("The backup couldn't be loaded either.\n\n%v\n\nDo you want to start a new game or quit?", err))
+				return
+			}
+			leaveRecovery()
+			frame.SetGame(game)
+			closeConfirm()
+			app.SetFocus(frame)
+		case "Quit":
+			quit()
+		}
+	})
+	// startNewGame starts a new game of difficulty d in a new save
+	// slot, once the current game is saved.
+	startNewGame := func(d sudoku.Difficulty) {
+		if err := save(); err != nil {
+			showMessage(fmt.Sprintf("The current game couldn't be saved, the new game isn't started.\n\n%v", err))
+			return
+		}
+		leaveRecovery()
+		useSlot(newSlotName())
+		frame.NewGame(time.Now().UnixNano(), d)
+		setUserPaused(false)
+		app.SetFocus(frame)
+	}
+
+	sidepane.GetButton(newGameButton).SetSelectedFunc(func() {
+		showConfirm("newgame")
+	})
+	newGameModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		closeConfirm()
+		if d, ok := sudoku.ParseDifficulty(buttonLabel); ok {
+			startNewGame(d)
+		}
+	})
+	// loadSlots are the save slots listed in loadModal.
+	var loadSlots []saveSlot
+	// showLoad lists the save slots in loadModal, the current one with
+	// the game as it is now, and shows it.
+	showLoad := func() {
+		slots, err := listSlots()
+		if err != nil {
+			showMessage(fmt.Sprintf("The saved games couldn't be listed.\n\n%v", err))
+			return
+		}
+		loadSlots = nil
+		if slot != "" {
+			loadSlots = append(loadSlots, saveSlot{name: slot, game: frame.Game()})
+		}
+		for _, s := range slots {
+			if s.name != slot {
+				loadSlots = append(loadSlots, s)
+			}
+		}
+		loadModal.Clear()
+		for _, s := range loadSlots {
+			name := s.name
+			if s.name == slot {
+				name += " (playing)"
+			}
+			if s.err != nil {
+				loadModal.AddItem(name, "can't be loaded: "+s.err.Error())
+			} else {
+				loadModal.AddItem(name, describeGame(s.game))
+			}
+		}
+		showConfirm("load")
+	}
+	sidepane.GetButton(loadGameButton).SetSelectedFunc(showLoad)
+	loadModal.SetDoneFunc(func(index int) {
+		closeConfirm()
+		if index < 0 || loadSlots[index].name == slot {
+			return
+		}
+		s := loadSlots[index]
+		if s.err != nil {
+			showMessage(fmt.Sprintf("The game couldn't be loaded.\n\n%v", s.err))
+			return
+		}
+		if err := save(); err != nil {
+			showMessage(fmt.Sprintf("The current game couldn't be saved, the game isn't loaded.\n\n%v", err))
+			return
+		}
+		leaveRecovery()
+		useSlot(s.name)
+		frame.SetGame(s.game)
+		setUserPaused(false)
+		app.SetFocus(frame)
+	})
+
+	sidepane.GetButton(exportButton).SetSelectedFunc(func() {
+		showConfirm("export")
+	})
+	// exportWhat is what of the puzzle is exported, while the user
+	// chooses the format.
+	var exportWhat string
+	exportModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		closeConfirm()
+		if buttonIndex < 0 || buttonIndex >= len(exportWhats) {
+			return
+		}
+		exportWhat = exportWhats[buttonIndex]
+		showConfirm("exportformat")
+	})
+	exportFormatModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		closeConfirm()
+		f, ok := sudoku.ParseFormat(buttonLabel)
+		if !ok {
+			return
+		}
+		name := strings.TrimSuffix(filepath.Base(savepath), ".json")
+		path, err := exportToFile(frame.grid.Board, name, exportWhat, f)
+		if err != nil {
+			showMessage(fmt.Sprintf("The puzzle couldn't be exported.\n\n%v", err))
+			return
+		}
+		showMessage("The puzzle is exported to " + path)
+	})
+
+	// progress holds the puzzles listed in collectionModal.
+	var progress []collectionPuzzle
+	// showCollection lists the puzzles of the collection along with
+	// their progress in collectionModal, and shows it.
+	showCollection := func() {
+		if collection == nil {
+			showMessage("No collection is open. Start the game with -collection to open one.")
+			return
+		}
+		progress = loadProgress(collection)
+		collectionModal.Clear()
+		// Place the cursor on the puzzle being played, or else on the
+		// first puzzle not solved yet.
+		current := -1
+		for i, p := range progress {
+			name := fmt.Sprintf("Puzzle %d", i+1)
+			if slot == "" && savepath == puzzlePath(p.puzzle) {
+				name += " (playing)"
+				p.game, p.err = frame.Game(), nil
+				current = i
+			} else if current < 0 && (p.game == nil || !p.game.Board.IsSolved()) {
+				current = i
+			}
+			collectionModal.AddItem(name, describeProgress(p))
+		}
+		if current >= 0 {
+			collectionModal.SetCurrentItem(current)
+		}
+		showConfirm("collection")
+	}
+	collectionModal.SetDoneFunc(func(index int) {
+		closeConfirm()
+		if index < 0 {
+			return
+		}
+		p := progress[index]
+		if slot == "" && savepath == puzzlePath(p.puzzle) {
+			return
+		}
+		if p.err != nil {
+			showMessage(fmt.Sprintf("The game couldn't be loaded.\n\n%v", p.err))
+			return
+		}
+		game := p.game
+		if game == nil {
+			if err := sudoku.CheckPuzzle(p.puzzle); err != nil {
+				showMessage(fmt.Sprintf("The puzzle can't be played.\n\n%v", err))
+				return
+			}
+			game = newGameFromPuzzle(p.puzzle)
+		}
+		if err := save(); err != nil {
+			showMessage(fmt.Sprintf("The current game couldn't be saved, the puzzle isn't opened.\n\n%v", err))
+			return
+		}
+		leaveRecovery()
+		usePuzzle(p.puzzle)
+		frame.SetGame(game)
+		setUserPaused(false)
+		app.SetFocus(frame)
+	})
+
+	if loadErr != nil {
+		recoveryModal.SetText(fmt.Sprintf("The saved game couldn't be loaded.\n\n%v\n\nDo you want to start a new game, open a backup of the save, or quit?", loadErr))
+		showConfirm("recovery")
+	} else if collection != nil {
+		showCollection()
+	}
+	if settingsErr != nil {
+		showMessage(fmt.Sprintf("The settings couldn't be read, the default settings are used.\n\n%v", settingsErr))
+	}
+
+	sidepane.GetButton(undoButton).SetSelectedFunc(func() {
 		frame.grid.Undo()
 	})
-	sidepane.GetButton(5).SetSelectedFunc(func() {
+	sidepane.GetButton(redoButton).SetSelectedFunc(func() {
+		frame.grid.Redo()
+	})
+	sidepane.GetButton(accentButton).SetSelectedFunc(func() {
 		pages.ShowPage("accent")
 	})
 	accentModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
@@ -164,41 +751,153 @@ c  Change Accent
 		case "Cyan", "Purple", "Pink", "Red", "Orange", "Yellow", "Green":
 			setAppThemeAccent(Theme, strings.ToLower(buttonLabel))
 		}
-		pages.SwitchToPage("grid")
+		pages.HidePage("accent")
 	})
-	sidepane.GetButton(1).SetSelectedFunc(func() {
-		pages.ShowPage("validate")
+	sidepane.GetButton(validateButton).SetSelectedFunc(func() {
+		showConfirm("validate")
 	})
-	validateModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		if buttonLabel == "Yes" {
-			// TODO: validating logic
+	sidepane.GetButton(solveButton).SetSelectedFunc(func() {
+		showConfirm("solve")
+	})
+	sidepane.GetButton(pauseButton).SetSelectedFunc(togglePause)
+	// hint shows the next step towards the solution, highlighting the
+	// cells involved. If the hint is still showing, it applies the step
+	// instead.
+	hint := func() {
+		if frame.grid.Locked() || frame.grid.Paused() {
+			return
+		}
+		if h := frame.grid.Hint(); h != nil {
+			h.Apply(frame.grid.Board)
+			pages.HidePage("hint")
+			hintModal.SetFocus(0)
+			return
+		}
+		h := sudoku.NextHint(frame.grid.Board)
+		if h == nil {
+			showMessage("No logical step was found from here. Validate the puzzle, or undo some entries and try again.")
+			return
+		}
+		frame.hints++
+		frame.grid.SetHint(h)
+		hintModal.SetText(h.String() + "\n\nPress H again, or Apply, to make this step.")
+		pages.ShowPage("hint")
+	}
+	sidepane.GetButton(hintButton).SetSelectedFunc(hint)
+	hintModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		pages.HidePage("hint")
+		hintModal.SetFocus(0)
+		if buttonLabel == "Apply" {
+			hint()
 		}
-		pages.SwitchToPage("grid")
-		validateModal.SetFocus(1)
 	})
-	sidepane.GetButton(2).SetSelectedFunc(func() {
-		pages.ShowPage("solve")
+	validateModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		closeConfirm()
+		validateModal.SetFocus(1)
+		if buttonLabel != "Yes" {
+			return
+		}
+		v := sudoku.Validate(frame.grid.Board)
+		frame.grid.SetInvalidCells(v.Invalid)
+		showMessage(v.String())
 	})
+	// cancelSolve cancels the last solve started.
+	cancelSolve := context.CancelFunc(func() {})
 	solveModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		if buttonLabel == "Yes" {
-			// TODO: auto-solve logic
-		}
-		pages.SwitchToPage("grid")
+		closeConfirm()
 		solveModal.SetFocus(0)
+		if buttonLabel != "Yes" {
+			return
+		}
+		// Search in the background, so that the user can cancel a
+		// search taking too long.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelSolve = cancel
+		digits := frame.grid.Digits()
+		showConfirm("solving")
+		go func() {
+			n, solution, err := sudoku.CountSolutions(ctx, digits, 2)
+			app.QueueUpdateDraw(func() {
+				// Canceled, or the grid changed in the meantime.
+				if err != nil || ctx.Err() != nil || frame.grid.Digits() != digits {
+					return
+				}
+				cancel()
+				closeConfirm()
+				switch n {
+				case 0:
+					showMessage("This puzzle has no solution with the current entries. Undo or clear some of them and try again.")
+				case 1:
+					frame.autoSolved = true
+					frame.grid.BeginTransaction()
+					for i, digit := range solution {
+						r, c := i/9, i%9
+						if !frame.grid.Cell(r, c).Readonly() {
+							frame.grid.SetCellWithUndo(r, c, digit)
+						}
+					}
+					frame.grid.CommitTransaction()
+					// Like once the user solves it, the game is over.
+					frame.grid.SetLocked(true)
+					frame.timer.Stop()
+				default:
+					showMessage("This puzzle has multiple solutions with the current entries.")
+				}
+			})
+		}()
+	})
+	solvingModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		cancelSolve()
+		closeConfirm()
 	})
-	sidepane.GetButton(3).SetSelectedFunc(func() {
-		pages.ShowPage("reset")
+	sidepane.GetButton(resetButton).SetSelectedFunc(func() {
+		showConfirm("reset")
 	})
 	resetModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		if buttonLabel == "Yes" {
 			frame.grid.ClearCells()
 		}
-		pages.SwitchToPage("grid")
+		closeConfirm()
 		resetModal.SetFocus(0)
 	})
+	frame.SetCompletedFunc(func() {
+		frame.timer.Stop()
+		if frame.autoSolved {
+			return
+		}
+		frame.grid.SetLocked(true)
+		victoryModal.SetText(fmt.Sprintf(
+			"Congratulations, you solved the puzzle!\n\nTime: %s\nDifficulty: %s\nMoves: %d\nHints: %d",
+			frame.timer.Elapsed(), frame.difficulty.GetText(true), frame.grid.Moves(), frame.hints,
+		))
+		pages.ShowPage("victory")
+	})
+	victoryModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		pages.HidePage("victory")
+		switch buttonLabel {
+		case "New game":
+			showConfirm("newgame")
+		case "Quit":
+			quit()
+		}
+	})
+	sidepane.GetButton(settingsButton).SetSelectedFunc(func() {
+		pages.ShowPage("settings")
+	})
+	settingsModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		toggles := settings.toggles()
+		if buttonIndex < 0 || buttonIndex >= len(toggles) {
+			pages.HidePage("settings")
+			settingsModal.SetFocus(0)
+			return
+		}
+		t := toggles[buttonIndex]
+		*t.value = !*t.value
+		settingsModal.SetButtonLabel(buttonIndex, toggleLabel(t))
+	})
 	helpModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		if buttonLabel == "Ok" {
-			pages.SwitchToPage("grid")
+			pages.HidePage("help")
 		}
 	})
 
@@ -210,6 +909,12 @@ c  Change Accent
 		focusRing = focusRing.Next()
 	}
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Leave the grid as it is while it's being solved, or until the
+		// user chose how to recover from a save which couldn't be
+		// loaded. Only the modal and quitting take keys.
+		if name, _ := pages.GetFrontPage(); (name == "solving" || recovering) && event.Key() != tcell.KeyCtrlC {
+			return event
+		}
 		switch event.Key() {
 		case tcell.KeyTAB:
 			// Figure out where the focus is currently. Needed because,
@@ -246,25 +951,58 @@ c  Change Accent
 				app.SetFocus(focusRing.Value.(tview.Primitive))
 				return nil
 			}
+		case tcell.KeyCtrlR:
+			frame.grid.Redo()
+			return nil
+		case tcell.KeyCtrlC:
+			quit()
+			return nil
 		case tcell.KeyRune:
 			switch event.Rune() {
+			case 'n':
+				showConfirm("newgame")
+				return nil
+			case 'L':
+				showLoad()
+				return nil
+			case 'e':
+				showConfirm("export")
+				return nil
+			case 'o':
+				showCollection()
+				return nil
 			case 'u':
 				frame.grid.Undo()
 				return nil
+			case 'U':
+				frame.grid.Redo()
+				return nil
+			case 'H':
+				hint()
+				return nil
+			case 'm':
+				frame.grid.SetNotesMode(!frame.grid.NotesMode())
+				return nil
 			case 'v':
-				pages.ShowPage("validate")
+				showConfirm("validate")
 				return nil
 			case 's':
-				pages.ShowPage("solve")
+				showConfirm("solve")
+				return nil
+			case 'p':
+				togglePause()
 				return nil
 			case 'c':
 				pages.ShowPage("accent")
 				return nil
+			case ',':
+				pages.ShowPage("settings")
+				return nil
 			case 't':
 				switchAppTheme()
 				return nil
 			case 'r':
-				pages.ShowPage("reset")
+				showConfirm("reset")
 				return nil
 			case '?':
 				pages.ShowPage("help")
@@ -274,29 +1012,142 @@ c  Change Accent
 		return event
 	})
 
-	frame.timer.Start()
+	// edits is the number of edits since the game was last autosaved.
+	edits := 0
+	// autosaveFailed is set while autosaving fails, so that the user is
+	// told only once.
+	autosaveFailed := false
+	autosave := func() {
+		if saved || quitWithoutSaving {
+			return
+		}
+		edits = 0
+		if err := save(); err != nil {
+			if !autosaveFailed {
+				showMessage(fmt.Sprintf("The game couldn't be autosaved.\n\n%v", err))
+			}
+			autosaveFailed = true
+			return
+		}
+		autosaveFailed = false
+	}
+	frame.SetChangedFunc(func() {
+		edits++
+		if edits == autosaveEdits {
+			// The edit may be part of a larger move, like a hint,
+			// autosave once it's done.
+			go app.QueueUpdate(autosave)
+		}
+	})
+	if autosaveFlag > 0 {
+		go func() {
+			for range time.Tick(time.Duration(autosaveFlag) * time.Second) {
+				app.QueueUpdate(func() {
+					// Nothing changed since the last autosave.
+					if edits == 0 && !frame.timer.Running() {
+						return
+					}
+					autosave()
+				})
+			}
+		}()
+	}
+
+	// Stop when asked to, closing the terminal included, and save the
+	// game on the way out.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-sigs
+		app.Stop()
+	}()
+	// Save the game before crashing too.
+	defer func() {
+		if p := recover(); p != nil {
+			if !saved && !quitWithoutSaving {
+				if err := save(); err != nil {
+					log.Println("saving the game:", err)
+				}
+			}
+			panic(p)
+		}
+	}()
+
+	if !frame.grid.Locked() && loadErr == nil {
+		frame.timer.Start()
+	}
 	if err := app.SetRoot(pages, true).SetFocus(pages).Run(); err != nil {
 		log.Println(err)
 	}
+	if saved || quitWithoutSaving {
+		return
+	}
+	// The application stopped without quitting, save what we can.
+	if err := save(); err != nil {
+		log.Fatalln("saving the game:", err)
+	}
+}
 
-	undofile, err := os.OpenFile(
-		undopath,
-		os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
-		0750,
-	)
-	if err != nil {
-		log.Fatalln(err)
+// importPuzzle returns a new game of the puzzle given by -puzzle or
+// -file, or nil if neither is given.
+func importPuzzle() (*sudoku.Game, error) {
+	var puzzle sudoku.Grid
+	switch {
+	case puzzleFlag != "":
+		var err error
+		if puzzle, err = sudoku.ParsePuzzle(puzzleFlag); err != nil {
+			return nil, fmt.Errorf("puzzle: %w", err)
+		}
+	case fileFlag != "":
+		f, err := os.Open(fileFlag)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		puzzles, err := sudoku.ReadPuzzles(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileFlag, err)
+		}
+		puzzle = puzzles[0]
+	default:
+		return nil, nil
 	}
-	defer undofile.Close()
-	savefile, err := os.OpenFile(
-		savepath,
-		os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
-		0750,
-	)
+	if err := sudoku.CheckPuzzle(puzzle); err != nil {
+		return nil, err
+	}
+	return newGameFromPuzzle(puzzle), nil
+}
+
+// loadGame reads the game saved in the file at path.
+func loadGame(path string) (*sudoku.Game, error) {
+	savefile, err := os.Open(path)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
 	}
 	defer savefile.Close()
+	return sudoku.ReadGame(savefile)
+}
 
-	frame.SavePuzzleToFile(savefile, undofile)
+// loadLegacyGame reads the game saved by older versions.
+func loadLegacyGame() (*sudoku.Game, error) {
+	savefile, err := os.Open(legacySavepath)
+	if err != nil {
+		return nil, err
+	}
+	defer savefile.Close()
+	undofile, err := os.Open(undopath)
+	if err != nil {
+		return nil, err
+	}
+	defer undofile.Close()
+	// The redo history is optional, the oldest versions didn't save it.
+	redofile, err := os.Open(redopath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if redofile != nil {
+		defer redofile.Close()
+		return sudoku.ReadLegacyGame(savefile, undofile, redofile)
+	}
+	return sudoku.ReadLegacyGame(savefile, undofile, nil)
 }
@@ -134,6 +134,13 @@ func (m *Modal) ClearButtons() *Modal {
 	return m
 }
 
+// SetButtonLabel sets the label of the button with the given index. The
+// "done" handler still receives the label the button was added with.
+func (m *Modal) SetButtonLabel(index int, label string) *Modal {
+	m.form.GetButton(index).SetLabel(label)
+	return m
+}
+
 // SetFocus shifts the focus to the button with the given index.
 func (m *Modal) SetFocus(index int) *Modal {
 	m.form.SetFocus(index)
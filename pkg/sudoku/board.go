@@ -0,0 +1,382 @@
+// Package sudoku implements the model of a sudoku game, free of any user
+// interface: a board with an undo and redo history, the save format, a
+// solver, a validator, a puzzle generator and a grader rating puzzles by
+// the human strategies they need.
+package sudoku
+
+// Cell is a cell of a Board.
+type Cell struct {
+	value    byte
+	readonly bool
+
+	// notes is the set of candidates pencilled in the cell.
+	notes Candidates
+}
+
+// Value returns the digit at c, or 0 if c is empty.
+func (c *Cell) Value() int {
+	if c.value == 0 {
+		return 0
+	}
+	return int(c.value - '0')
+}
+
+// SetValue sets the digit at c. 0 empties c.
+func (c *Cell) SetValue(digit int) *Cell {
+	if digit == 0 {
+		c.value = 0
+	} else {
+		c.value = byte(digit) + '0'
+	}
+	return c
+}
+
+// Readonly reports whether c is a given of the puzzle.
+func (c *Cell) Readonly() bool {
+	return c.readonly
+}
+
+// SetReadonly sets whether c is a given of the puzzle.
+func (c *Cell) SetReadonly(v bool) *Cell {
+	c.readonly = v
+	return c
+}
+
+// Rune returns the digit at c as a rune, or ' ' if c is empty.
+func (c *Cell) Rune() rune {
+	if c.value == 0 {
+		return ' '
+	}
+	return rune(c.value)
+}
+
+// IsEmpty reports whether c holds no digit.
+func (c *Cell) IsEmpty() bool {
+	return c.value == 0
+}
+
+// Notes returns the set of candidates noted in c.
+func (c *Cell) Notes() Candidates {
+	return c.notes
+}
+
+// SetNotes sets the candidates noted in c to notes.
+func (c *Cell) SetNotes(notes Candidates) *Cell {
+	c.notes = notes & AllCandidates
+	return c
+}
+
+// HasNote reports whether digit is noted in c.
+func (c *Cell) HasNote(digit int) bool {
+	return c.notes.Has(digit)
+}
+
+// Change is the state of a cell before it was edited.
+type Change struct {
+	Row, Col, Digit int
+	Notes           Candidates
+}
+
+// Move is undone, or redone, as a whole. It holds the state of every
+// cell edited by the move, in the order of the edits.
+type Move []Change
+
+// Board is a sudoku puzzle being solved. It keeps the history of the
+// edits made to it, so that they can be undone and redone.
+type Board struct {
+	cells       [81]Cell
+	undoHistory []Move
+
+	// redoHistory holds the moves undone since the last edit, the most
+	// recently undone last.
+	redoHistory []Move
+
+	// transaction collects the edits made between BeginTransaction
+	// and the matching CommitTransaction into a single move.
+	// transactionDepth counts the transactions begun but not yet
+	// committed, so transactions can nest.
+	transaction      Move
+	transactionDepth int
+
+	// moves counts the moves made on the board, a transaction counting
+	// as one move. Undoing a move doesn't take it back from the count.
+	moves int
+
+	// completed is called whenever an edit completes the board with a
+	// correct solution.
+	completed func()
+
+	// changed is called whenever the digit or notes of a cell change
+	// through an edit, an undo or a redo.
+	changed func()
+}
+
+// NewBoard returns a Board holding the puzzle givens. The filled cells
+// of givens are readonly.
+func NewBoard(givens Grid) *Board {
+	b := &Board{}
+	for i, digit := range givens {
+		if digit != 0 {
+			b.cells[i].SetValue(digit).SetReadonly(true)
+		}
+	}
+	return b
+}
+
+// Cell returns the cell at row r and column c.
+func (b *Board) Cell(r, c int) *Cell {
+	return &b.cells[9*r+c]
+}
+
+// Digits returns the digit of every cell of b.
+func (b *Board) Digits() Grid {
+	var digits Grid
+	for i := range b.cells {
+		digits[i] = b.cells[i].Value()
+	}
+	return digits
+}
+
+// Givens returns the digit of every readonly cell of b.
+func (b *Board) Givens() Grid {
+	var givens Grid
+	for i := range b.cells {
+		if b.cells[i].Readonly() {
+			givens[i] = b.cells[i].Value()
+		}
+	}
+	return givens
+}
+
+// ClearCells clears the value and notes of all non-readonly cells. It
+// can be undone in a single step.
+func (b *Board) ClearCells() *Board {
+	b.BeginTransaction()
+	for r := 0; r < 9; r++ {
+		for c := 0; c < 9; c++ {
+			if !b.Cell(r, c).Readonly() {
+				b.SetCellWithUndo(r, c, 0)
+				b.SetNotesWithUndo(r, c, 0)
+			}
+		}
+	}
+	b.CommitTransaction()
+	return b
+}
+
+// BeginTransaction groups every edit made until the matching
+// CommitTransaction into a single move of the undo history. Bulk
+// operations use it so that they can be undone in one go.
+// Transactions can nest, in which case the edits are grouped into the
+// outermost one.
+func (b *Board) BeginTransaction() *Board {
+	b.transactionDepth++
+	return b
+}
+
+// CommitTransaction ends the transaction begun by the last
+// BeginTransaction. Once the outermost transaction is committed, its
+// edits are stored in the undo history as a single move. A transaction
+// without edits leaves the history untouched.
+func (b *Board) CommitTransaction() *Board {
+	if b.transactionDepth == 0 {
+		return b
+	}
+	b.transactionDepth--
+	if b.transactionDepth == 0 && len(b.transaction) > 0 {
+		b.undoHistory = append(b.undoHistory, b.transaction)
+		b.redoHistory = nil
+		b.transaction = nil
+		b.moves++
+		b.checkCompleted()
+	}
+	return b
+}
+
+// SetCellWithoutUndo sets the value of cell at row r and column c with
+// the value digit. It doesn't store the previous value of the cell in
+// it's undo history.
+func (b *Board) SetCellWithoutUndo(r, c, digit int) *Board {
+	b.Cell(r, c).SetValue(digit)
+	return b
+}
+
+// SetCellWithUndo sets the value of cell at row r and column c with the
+// value digit. It also stores the previous value of the cell in it's
+// undo history.
+func (b *Board) SetCellWithUndo(r, c, digit int) *Board {
+	cell := b.Cell(r, c)
+	if digit == cell.Value() {
+		return b
+	}
+	b.pushUndo(r, c)
+	cell.SetValue(digit)
+	b.notifyChanged()
+	if b.transactionDepth == 0 {
+		b.checkCompleted()
+	}
+	return b
+}
+
+// SetNotesWithUndo sets the notes of cell at row r and column c to
+// notes. It also stores the previous notes of the cell in it's undo
+// history.
+func (b *Board) SetNotesWithUndo(r, c int, notes Candidates) *Board {
+	cell := b.Cell(r, c)
+	if notes == cell.Notes() {
+		return b
+	}
+	b.pushUndo(r, c)
+	cell.SetNotes(notes)
+	b.notifyChanged()
+	return b
+}
+
+// ToggleNoteWithUndo notes digit in cell at row r and column c, or
+// removes it if it was already noted. The change is stored in the undo
+// history.
+func (b *Board) ToggleNoteWithUndo(r, c, digit int) *Board {
+	return b.SetNotesWithUndo(r, c, b.Cell(r, c).Notes().Toggle(digit))
+}
+
+// IsSolved reports whether every cell of b is filled without breaking a
+// row, column or box constraint.
+func (b *Board) IsSolved() bool {
+	digits := b.Digits()
+	for _, d := range digits {
+		if d == 0 {
+			return false
+		}
+	}
+	_, ok := newSolver(digits)
+	return ok
+}
+
+// SetCompletedFunc sets the handler which is called whenever an edit
+// completes the board with a correct solution.
+func (b *Board) SetCompletedFunc(handler func()) *Board {
+	b.completed = handler
+	return b
+}
+
+// SetChangedFunc sets the handler which is called whenever the digit or
+// notes of a cell change through an edit, an undo or a redo.
+func (b *Board) SetChangedFunc(handler func()) *Board {
+	b.changed = handler
+	return b
+}
+
+// checkCompleted calls the completed handler if b is solved.
+func (b *Board) checkCompleted() {
+	if b.completed != nil && b.IsSolved() {
+		b.completed()
+	}
+}
+
+// notifyChanged calls the changed handler, if any.
+func (b *Board) notifyChanged() {
+	if b.changed != nil {
+		b.changed()
+	}
+}
+
+// Moves returns the number of moves made on b.
+func (b *Board) Moves() int {
+	return b.moves
+}
+
+// SetMoves sets the number of moves made on b to n.
+func (b *Board) SetMoves(n int) *Board {
+	b.moves = n
+	return b
+}
+
+// cellState returns the state of cell at row r and column c.
+func (b *Board) cellState(r, c int) Change {
+	cell := b.Cell(r, c)
+	return Change{r, c, cell.Value(), cell.Notes()}
+}
+
+// pushUndo stores the state of cell at row r and column c in the undo
+// history before it is edited. Since it's a new edit, the redo history
+// is discarded. Inside a transaction, the state is added to the
+// transaction instead.
+func (b *Board) pushUndo(r, c int) {
+	if b.transactionDepth > 0 {
+		b.transaction = append(b.transaction, b.cellState(r, c))
+		return
+	}
+	b.undoHistory = append(b.undoHistory, Move{b.cellState(r, c)})
+	b.redoHistory = nil
+	b.moves++
+}
+
+// restore restores the cell of change to the state stored in change,
+// and returns the state it replaced.
+func (b *Board) restore(change Change) Change {
+	prev := b.cellState(change.Row, change.Col)
+	b.Cell(change.Row, change.Col).SetValue(change.Digit).SetNotes(change.Notes)
+	return prev
+}
+
+// restoreMove restores every cell of move, and returns the states they
+// replaced as a move. Undoing restores the cells in the reverse order
+// of the edits, redoing in the order of the edits, so that a cell
+// edited more than once in a move ends up in the right state.
+func (b *Board) restoreMove(move Move, reverse bool) Move {
+	prev := make(Move, len(move))
+	for k := range move {
+		i := k
+		if reverse {
+			i = len(move) - 1 - k
+		}
+		prev[i] = b.restore(move[i])
+	}
+	b.notifyChanged()
+	return prev
+}
+
+// Undo undos the last move. The move can be redone with Redo.
+func (b *Board) Undo() *Board {
+	if len(b.undoHistory) > 0 {
+		move := b.undoHistory[len(b.undoHistory)-1]
+		b.undoHistory = b.undoHistory[:len(b.undoHistory)-1]
+		b.redoHistory = append(b.redoHistory, b.restoreMove(move, true))
+	}
+	return b
+}
+
+// Redo redos the last undone move.
+func (b *Board) Redo() *Board {
+	if len(b.redoHistory) > 0 {
+		move := b.redoHistory[len(b.redoHistory)-1]
+		b.redoHistory = b.redoHistory[:len(b.redoHistory)-1]
+		b.undoHistory = append(b.undoHistory, b.restoreMove(move, false))
+		b.checkCompleted()
+	}
+	return b
+}
+
+// UndoHistory returns the moves that can be undone, the most recent
+// last.
+func (b *Board) UndoHistory() []Move {
+	return b.undoHistory
+}
+
+// SetUndoHistory replaces the undo history of b with history.
+func (b *Board) SetUndoHistory(history []Move) *Board {
+	b.undoHistory = history
+	return b
+}
+
+// RedoHistory returns the moves that can be redone, the most recently
+// undone last.
+func (b *Board) RedoHistory() []Move {
+	return b.redoHistory
+}
+
+// SetRedoHistory replaces the redo history of b with history.
+func (b *Board) SetRedoHistory(history []Move) *Board {
+	b.redoHistory = history
+	return b
+}
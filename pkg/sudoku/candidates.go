@@ -0,0 +1,55 @@
+package sudoku
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// Candidates is a set of digits, used for the notes of a cell. Bit d-1
+// is set if digit d is in the set.
+type Candidates uint16
+
+// AllCandidates is the set of every digit from 1 to 9.
+const AllCandidates Candidates = 1<<9 - 1
+
+// Has reports whether digit is in c.
+func (c Candidates) Has(digit int) bool {
+	return c&(1<<(digit-1)) != 0
+}
+
+// Toggle returns c with digit added if it wasn't in c, or removed if it
+// was.
+func (c Candidates) Toggle(digit int) Candidates {
+	return c ^ 1<<(digit-1)
+}
+
+// Count returns the number of digits in c.
+func (c Candidates) Count() int {
+	return bits.OnesCount16(uint16(c))
+}
+
+// Digits returns the digits of c in increasing order.
+func (c Candidates) Digits() []int {
+	return digitsOf(uint16(c))
+}
+
+// String returns the digits of c in increasing order, like "139".
+func (c Candidates) String() string {
+	var s strings.Builder
+	for _, d := range c.Digits() {
+		s.WriteByte(byte(d) + '0')
+	}
+	return s.String()
+}
+
+// ParseCandidates parses candidates formatted by Candidates.String. ok
+// is false if s contains anything other than the digits 1 to 9.
+func ParseCandidates(s string) (c Candidates, ok bool) {
+	for _, b := range []byte(s) {
+		if b < '1' || b > '9' {
+			return 0, false
+		}
+		c |= 1 << (b - '1')
+	}
+	return c, true
+}
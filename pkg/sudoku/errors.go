@@ -0,0 +1,53 @@
+package sudoku
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ParseError is the error returned when a save or a history can't be
+// parsed. It locates the problem in the input.
+type ParseError struct {
+	// File names the input, if there is more than one, or is empty.
+	File string
+
+	// Line and Column locate the problem, counting from 1. Column is 0
+	// if the problem is with the whole line, and both are 0 if the
+	// problem is with the whole input, like a missing line.
+	Line, Column int
+
+	// Err describes the problem.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var s string
+	switch {
+	case e.Line == 0:
+		s = e.Err.Error()
+	case e.Column == 0:
+		s = fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	default:
+		s = fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+	if e.File != "" {
+		return e.File + ": " + s
+	}
+	return s
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// errorAt returns a ParseError locating offset, counted in bytes from
+// the start of data.
+func errorAt(data []byte, offset int, err error) *ParseError {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte{'\n'}) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return &ParseError{Line: line, Column: column, Err: err}
+}
@@ -0,0 +1,198 @@
+package sudoku
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadPuzzles reads the puzzles of r, in any of the common text formats:
+//
+//   - a line of 81 cells per puzzle, as in .sdm files
+//   - 9 lines of 9 cells, as in SadMan .sdk files
+//   - 9 lines of 9 cells split into boxes by '|', with lines of '-'
+//     between the bands, as in Simple Sudoku .ss files
+//
+// An empty cell is denoted by one of ".0xX*_". Spaces and the '|', '-'
+// and '+' drawing the boxes are ignored, as are lines starting with '#'
+// or '[', like the comments and section headers of .sdk files. Problems
+// with the contents of r are reported as a *ParseError.
+func ReadPuzzles(r io.Reader) ([]Grid, error) {
+	var puzzles []Grid
+	// cells holds the cells of the puzzle being read.
+	var cells []int
+	// start is the line the puzzle being read starts at.
+	start := 0
+	scan := bufio.NewScanner(r)
+	for line := 1; scan.Scan(); line++ {
+		text := scan.Text()
+		if t := strings.TrimSpace(text); strings.HasPrefix(t, "#") || strings.HasPrefix(t, "[") {
+			continue
+		}
+		var row []int
+		for i, ch := range text {
+			switch {
+			case strings.ContainsRune(" \t\r|-+", ch):
+			case strings.ContainsRune(".0xX*_", ch):
+				row = append(row, 0)
+			case ch >= '1' && ch <= '9':
+				row = append(row, int(ch-'0'))
+			default:
+				return nil, &ParseError{Line: line, Column: i + 1, Err: fmt.Errorf("character %q must be a digit or one of \".0xX*_\"", ch)}
+			}
+		}
+		switch {
+		case len(row) == 0:
+			continue
+		case len(row) == 81 && len(cells) == 0:
+		case len(row) == 9:
+			if len(cells) == 0 {
+				start = line
+			}
+		default:
+			return nil, &ParseError{Line: line, Err: fmt.Errorf("have %d cells, want 9 or 81", len(row))}
+		}
+		cells = append(cells, row...)
+		if len(cells) == 81 {
+			var g Grid
+			copy(g[:], cells)
+			puzzles = append(puzzles, g)
+			cells = cells[:0]
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+	if len(cells) > 0 {
+		return nil, &ParseError{Line: start, Err: fmt.Errorf("puzzle has %d rows, want 9", len(cells)/9)}
+	}
+	if len(puzzles) == 0 {
+		return nil, &ParseError{Err: errors.New("no puzzle")}
+	}
+	return puzzles, nil
+}
+
+// ParsePuzzle parses a single puzzle in any of the formats read by
+// ReadPuzzles.
+func ParsePuzzle(s string) (Grid, error) {
+	puzzles, err := ReadPuzzles(strings.NewReader(s))
+	if err != nil {
+		return Grid{}, err
+	}
+	if len(puzzles) > 1 {
+		return Grid{}, &ParseError{Err: fmt.Errorf("have %d puzzles, want 1", len(puzzles))}
+	}
+	return puzzles[0], nil
+}
+
+// Format is a text format puzzles are written in.
+type Format int
+
+const (
+	// FormatLine is a line of 81 cells.
+	FormatLine Format = iota
+	// FormatSDK is the SadMan .sdk format, 9 lines of 9 cells.
+	FormatSDK
+	// FormatSS is the Simple Sudoku .ss format, 9 lines of 9 cells
+	// split into boxes by '|', with lines of '-' between the bands.
+	FormatSS
+	// FormatGrid is a grid drawn with box drawing characters, like the
+	// game draws it. It can't be read back.
+	FormatGrid
+)
+
+var formatNames = [...]string{"line", "sdk", "ss", "grid"}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// ParseFormat returns the Format named s, ignoring case. ok is false if
+// no Format is named s.
+func ParseFormat(s string) (f Format, ok bool) {
+	for i, name := range formatNames {
+		if strings.EqualFold(s, name) {
+			return Format(i), true
+		}
+	}
+	return FormatLine, false
+}
+
+// Formats returns the names of every Format.
+func Formats() []string {
+	return append([]string(nil), formatNames[:]...)
+}
+
+// WritePuzzle writes g to w in format f, with '.' denoting an empty
+// cell, or a space in FormatGrid.
+func WritePuzzle(w io.Writer, g Grid, f Format) error {
+	var s strings.Builder
+	cell := func(i int) byte {
+		if g[i] == 0 {
+			return '.'
+		}
+		return byte(g[i]) + '0'
+	}
+	switch f {
+	case FormatLine:
+		s.WriteString(g.String())
+		s.WriteByte('\n')
+	case FormatSDK:
+		for r := 0; r < 9; r++ {
+			for c := 0; c < 9; c++ {
+				s.WriteByte(cell(9*r + c))
+			}
+			s.WriteByte('\n')
+		}
+	case FormatSS:
+		for r := 0; r < 9; r++ {
+			if r == 3 || r == 6 {
+				s.WriteString("-----------\n")
+			}
+			for c := 0; c < 9; c++ {
+				if c == 3 || c == 6 {
+					s.WriteByte('|')
+				}
+				s.WriteByte(cell(9*r + c))
+			}
+			s.WriteByte('\n')
+		}
+	case FormatGrid:
+		writeGrid(&s, g)
+	default:
+		return fmt.Errorf("unknown format %d", f)
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// writeGrid writes g to s as a grid drawn with box drawing characters,
+// light lines separating the cells and heavy lines the boxes.
+func writeGrid(s *strings.Builder, g Grid) {
+	for r := 0; r < 9; r++ {
+		switch {
+		case r == 3 || r == 6:
+			s.WriteString("━━━━━━━━━━━╋━━━━━━━━━━━╋━━━━━━━━━━━\n")
+		case r > 0:
+			s.WriteString("╶─╴ ╶─╴ ╶─╴┃╶─╴ ╶─╴ ╶─╴┃╶─╴ ╶─╴ ╶─╴\n")
+		}
+		for c := 0; c < 9; c++ {
+			switch {
+			case c == 3 || c == 6:
+				s.WriteString("┃")
+			case c > 0:
+				s.WriteString("│")
+			}
+			d := byte(' ')
+			if g[9*r+c] != 0 {
+				d = byte(g[9*r+c]) + '0'
+			}
+			s.WriteByte(' ')
+			s.WriteByte(d)
+			s.WriteByte(' ')
+		}
+		s.WriteByte('\n')
+	}
+}
@@ -0,0 +1,107 @@
+package sudoku
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testSolution is the solution of testPuzzle.
+const testSolution = "534678912672195348198342567859761423426853791713924856961537284287419635345286179"
+
+// rows returns the rows of the puzzle p, a line of 81 cells, with each
+// row formatted by row.
+func rows(p string, row func(r int, cells string) string) string {
+	var s strings.Builder
+	for r := 0; r < 9; r++ {
+		s.WriteString(row(r, p[9*r:9*r+9]))
+	}
+	return s.String()
+}
+
+func TestReadPuzzles(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "line with dots",
+			input: testPuzzle + "\n",
+			want:  []string{testPuzzle},
+		},
+		{
+			name:  "line with zeros",
+			input: strings.ReplaceAll(testPuzzle, ".", "0"),
+			want:  []string{testPuzzle},
+		},
+		{
+			name: "sdk",
+			input: "[Puzzle]\n# A comment\n#A Author\n" + rows(testPuzzle, func(r int, cells string) string {
+				return cells + "\n"
+			}),
+			want: []string{testPuzzle},
+		},
+		{
+			name: "ss",
+			input: rows(testPuzzle, func(r int, cells string) string {
+				s := cells[0:3] + "|" + cells[3:6] + "|" + cells[6:9] + "\n"
+				if r == 3 || r == 6 {
+					s = "---+---+---\n" + s
+				}
+				return s
+			}),
+			want: []string{testPuzzle},
+		},
+		{
+			name:  "sdm",
+			input: testPuzzle + "\r\n" + testSolution + "\r\n\r\n" + strings.ReplaceAll(testPuzzle, ".", "0") + "\r\n",
+			want:  []string{testPuzzle, testSolution, testPuzzle},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzles, err := ReadPuzzles(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(puzzles) != len(tt.want) {
+				t.Fatalf("got %d puzzles, want %d", len(puzzles), len(tt.want))
+			}
+			for i, p := range puzzles {
+				if p.String() != tt.want[i] {
+					t.Errorf("puzzle %d: got %s, want %s", i+1, p, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadPuzzlesError(t *testing.T) {
+	sdk := rows(testPuzzle, func(r int, cells string) string {
+		return cells + "\n"
+	})
+	tests := []struct {
+		name         string
+		input        string
+		line, column int
+	}{
+		{"bad character in a line", testPuzzle[:10] + "a" + testPuzzle[11:], 1, 11},
+		{"bad character in a row", "# A comment\n" + strings.Replace(sdk, "419", "4!9", 1), 9, 5},
+		{"short row", "# A comment\n" + strings.Replace(sdk, "..419", ".419", 1), 9, 0},
+		{"short final puzzle", testPuzzle + "\n" + sdk[:8*10], 2, 0},
+		{"no puzzle", "# A comment\n", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadPuzzles(strings.NewReader(tt.input))
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			if perr.Line != tt.line || perr.Column != tt.column {
+				t.Errorf("got line %d, column %d (%v), want line %d, column %d", perr.Line, perr.Column, err, tt.line, tt.column)
+			}
+		})
+	}
+}
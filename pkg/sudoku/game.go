@@ -0,0 +1,270 @@
+package sudoku
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Game is a puzzle being played: its board along with the time spent on
+// it, its difficulty and the hints used.
+type Game struct {
+	Board *Board
+
+	// Elapsed is the time spent on the puzzle, in seconds.
+	Elapsed int
+
+	Difficulty Difficulty
+
+	// Seed is the seed the puzzle was generated from, or 0 if it
+	// wasn't generated.
+	Seed int64
+
+	// Hints is the number of hints used.
+	Hints int
+
+	// Created is when the game was started, and Saved when it was last
+	// saved. Games migrated from legacy saves have a zero Created.
+	Created, Saved time.Time
+}
+
+// SaveVersion is the version of the save format written by Game.Write.
+// It is increased whenever the format changes in a way older versions
+// can't read.
+const SaveVersion = 1
+
+// save is the JSON document of a saved Game.
+type save struct {
+	Version int `json:"version"`
+
+	// Givens and Entries hold the digits of the readonly and the other
+	// cells respectively, as a line of 81 characters with '.' denoting
+	// an empty cell.
+	Givens  string `json:"givens"`
+	Entries string `json:"entries"`
+
+	// Notes holds the notes of every cell like "139", or "" for none.
+	Notes []string `json:"notes"`
+
+	Undo []savedMove `json:"undo"`
+	Redo []savedMove `json:"redo"`
+
+	// Elapsed is in seconds.
+	Elapsed    int       `json:"elapsed"`
+	Difficulty string    `json:"difficulty"`
+	Seed       int64     `json:"seed"`
+	Moves      int       `json:"moves"`
+	Hints      int       `json:"hints"`
+	Created    time.Time `json:"created"`
+	Saved      time.Time `json:"saved"`
+}
+
+// savedMove is the JSON document of a Move.
+type savedMove []savedChange
+
+// savedChange is the JSON document of a Change.
+type savedChange struct {
+	Row   int    `json:"row"`
+	Col   int    `json:"col"`
+	Digit int    `json:"digit"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// Write writes g to w as a JSON document of version SaveVersion.
+func (g *Game) Write(w io.Writer) error {
+	b := g.Board
+	s := save{
+		Version:    SaveVersion,
+		Notes:      make([]string, 81),
+		Elapsed:    g.Elapsed,
+		Difficulty: g.Difficulty.String(),
+		Seed:       g.Seed,
+		Moves:      b.Moves(),
+		Hints:      g.Hints,
+		Created:    g.Created,
+		Saved:      g.Saved,
+	}
+	var givens, entries Grid
+	for i := range b.cells {
+		cell := &b.cells[i]
+		if cell.Readonly() {
+			givens[i] = cell.Value()
+		} else {
+			entries[i] = cell.Value()
+		}
+		if cell.Notes() != 0 {
+			s.Notes[i] = cell.Notes().String()
+		}
+	}
+	s.Givens, s.Entries = givens.String(), entries.String()
+	s.Undo = saveHistory(b.undoHistory)
+	s.Redo = saveHistory(b.redoHistory)
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(s)
+}
+
+func saveHistory(history []Move) []savedMove {
+	moves := make([]savedMove, len(history))
+	for i, move := range history {
+		moves[i] = make(savedMove, len(move))
+		for j, c := range move {
+			var notes string
+			if c.Notes != 0 {
+				notes = c.Notes.String()
+			}
+			moves[i][j] = savedChange{c.Row, c.Col, c.Digit, notes}
+		}
+	}
+	return moves
+}
+
+// ReadGame reads a game written by Game.Write from r. Problems with the
+// contents of r are reported as a *ParseError.
+func ReadGame(r io.Reader) (*Game, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var s save
+	if err := json.Unmarshal(data, &s); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return nil, errorAt(data, int(syntaxErr.Offset), err)
+		case errors.As(err, &typeErr):
+			return nil, errorAt(data, int(typeErr.Offset), err)
+		}
+		return nil, &ParseError{Err: err}
+	}
+	// fieldError returns a ParseError locating offset bytes into the
+	// value of field name.
+	fieldError := func(name string, offset int, err error) error {
+		return errorAt(data, fieldOffset(data, name)+offset, fmt.Errorf("%s: %w", name, err))
+	}
+	if s.Version < 1 || s.Version > SaveVersion {
+		return nil, fieldError("version", 0, fmt.Errorf("unsupported version %d, want at most %d", s.Version, SaveVersion))
+	}
+
+	b := &Board{}
+	givens, i, err := parseGrid(s.Givens)
+	if err != nil {
+		// 1 skips the opening quote of the string.
+		return nil, fieldError("givens", 1+i, err)
+	}
+	entries, i, err := parseGrid(s.Entries)
+	if err != nil {
+		return nil, fieldError("entries", 1+i, err)
+	}
+	for i := range b.cells {
+		switch {
+		case givens[i] != 0 && entries[i] != 0:
+			return nil, fieldError("entries", 1+i, fmt.Errorf("%s holds both a given and an entry", CellName(i)))
+		case givens[i] != 0:
+			b.cells[i].SetValue(givens[i]).SetReadonly(true)
+		default:
+			b.cells[i].SetValue(entries[i])
+		}
+	}
+	if s.Notes != nil {
+		if len(s.Notes) != 81 {
+			return nil, fieldError("notes", 0, fmt.Errorf("have %d cells, want 81", len(s.Notes)))
+		}
+		for i, field := range s.Notes {
+			notes, ok := ParseCandidates(field)
+			if !ok {
+				return nil, fieldError("notes", 0, fmt.Errorf("notes of %s must be in the set [1-9]", CellName(i)))
+			}
+			b.cells[i].SetNotes(notes)
+		}
+	}
+	if b.undoHistory, err = loadHistory(b, s.Undo); err != nil {
+		return nil, fieldError("undo", 0, err)
+	}
+	if b.redoHistory, err = loadHistory(b, s.Redo); err != nil {
+		return nil, fieldError("redo", 0, err)
+	}
+	b.SetMoves(s.Moves)
+
+	d, ok := ParseDifficulty(s.Difficulty)
+	if !ok {
+		return nil, fieldError("difficulty", 0, fmt.Errorf("unknown difficulty %q", s.Difficulty))
+	}
+	return &Game{
+		Board:      b,
+		Elapsed:    s.Elapsed,
+		Difficulty: d,
+		Seed:       s.Seed,
+		Hints:      s.Hints,
+		Created:    s.Created,
+		Saved:      s.Saved,
+	}, nil
+}
+
+// loadHistory returns the history of moves on board b. A move may not
+// change a given of b, undoing it would overwrite the given.
+func loadHistory(b *Board, moves []savedMove) ([]Move, error) {
+	var history []Move
+	for i, m := range moves {
+		if len(m) == 0 {
+			continue
+		}
+		move := make(Move, len(m))
+		for j, c := range m {
+			if c.Row < 0 || c.Row > 8 || c.Col < 0 || c.Col > 8 {
+				return nil, fmt.Errorf("move %d: cell (%d, %d) out of the grid", i+1, c.Row, c.Col)
+			}
+			if b.Cell(c.Row, c.Col).Readonly() {
+				return nil, fmt.Errorf("move %d: %s is a given", i+1, CellName(9*c.Row+c.Col))
+			}
+			if c.Digit < 0 || c.Digit > 9 {
+				return nil, fmt.Errorf("move %d: digit %d must be in the range [0-9]", i+1, c.Digit)
+			}
+			notes, ok := ParseCandidates(c.Notes)
+			if !ok {
+				return nil, fmt.Errorf("move %d: notes must be in the set [1-9]", i+1)
+			}
+			move[j] = Change{c.Row, c.Col, c.Digit, notes}
+		}
+		history = append(history, move)
+	}
+	return history, nil
+}
+
+// parseGrid parses a grid formatted by Grid.String. On error, i is the
+// index of the offending character in s.
+func parseGrid(s string) (g Grid, i int, err error) {
+	for i = 0; i < len(s) && i < 81; i++ {
+		switch c := s[i]; {
+		case c == '.':
+		case c >= '1' && c <= '9':
+			g[i] = int(c - '0')
+		default:
+			return g, i, fmt.Errorf("character %q must be in the set [.1-9]", c)
+		}
+	}
+	if len(s) != 81 {
+		return g, 0, fmt.Errorf("have %d cells, want 81", len(s))
+	}
+	return g, 0, nil
+}
+
+// fieldOffset returns the offset in data of the value of the top level
+// field name of a JSON object, or 0 if name isn't found.
+func fieldOffset(data []byte, name string) int {
+	key := []byte(`"` + name + `"`)
+	i := bytes.Index(data, key)
+	if i < 0 {
+		return 0
+	}
+	i += len(key)
+	for i < len(data) && (data[i] == ':' || data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+	return i
+}
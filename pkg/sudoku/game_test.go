@@ -0,0 +1,161 @@
+package sudoku
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPuzzle is a puzzle with a unique solution.
+const testPuzzle = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+
+func mustParsePuzzle(t *testing.T, s string) Grid {
+	t.Helper()
+	g, err := ParsePuzzle(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// checkBoard fails t if the cells or the histories of got and want
+// differ.
+func checkBoard(t *testing.T, got, want *Board) {
+	t.Helper()
+	for i := range want.cells {
+		g, w := &got.cells[i], &want.cells[i]
+		if g.Value() != w.Value() || g.Readonly() != w.Readonly() || g.Notes() != w.Notes() {
+			t.Errorf("%s: got digit %d, readonly %v, notes %q; want digit %d, readonly %v, notes %q",
+				CellName(i), g.Value(), g.Readonly(), g.Notes(), w.Value(), w.Readonly(), w.Notes())
+		}
+	}
+	if !reflect.DeepEqual(got.UndoHistory(), want.UndoHistory()) {
+		t.Errorf("undo history: got %v, want %v", got.UndoHistory(), want.UndoHistory())
+	}
+	if !reflect.DeepEqual(got.RedoHistory(), want.RedoHistory()) {
+		t.Errorf("redo history: got %v, want %v", got.RedoHistory(), want.RedoHistory())
+	}
+	if got.Moves() != want.Moves() {
+		t.Errorf("moves: got %d, want %d", got.Moves(), want.Moves())
+	}
+}
+
+func TestGameRoundTrip(t *testing.T) {
+	b := NewBoard(mustParsePuzzle(t, testPuzzle))
+	b.SetCellWithUndo(0, 2, 4)
+	b.ToggleNoteWithUndo(0, 3, 2)
+	b.ToggleNoteWithUndo(0, 3, 6)
+	b.BeginTransaction()
+	b.SetCellWithUndo(0, 5, 8)
+	b.SetNotesWithUndo(1, 1, Candidates(0).Toggle(2).Toggle(7))
+	b.SetCellWithUndo(1, 1, 7)
+	b.CommitTransaction()
+	b.BeginTransaction()
+	b.SetCellWithUndo(8, 0, 3)
+	b.SetCellWithUndo(8, 1, 4)
+	b.CommitTransaction()
+	b.Undo()
+	b.SetCellWithUndo(8, 1, 1)
+	b.Undo()
+
+	want := &Game{
+		Board:      b,
+		Elapsed:    754,
+		Difficulty: Hard,
+		Seed:       42,
+		Hints:      3,
+		Created:    time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC),
+		Saved:      time.Date(2023, 4, 6, 7, 8, 9, 0, time.UTC),
+	}
+	var buf bytes.Buffer
+	if err := want.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadGame(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkBoard(t, got.Board, b)
+	if n := len(got.Board.UndoHistory()); n != 4 {
+		t.Errorf("got %d moves to undo, want 4", n)
+	}
+	if n := len(got.Board.RedoHistory()); n != 1 {
+		t.Errorf("got %d moves to redo, want 1", n)
+	}
+	if got.Elapsed != want.Elapsed || got.Difficulty != want.Difficulty || got.Seed != want.Seed || got.Hints != want.Hints {
+		t.Errorf("got elapsed %d, difficulty %s, seed %d, hints %d; want %d, %s, %d, %d",
+			got.Elapsed, got.Difficulty, got.Seed, got.Hints, want.Elapsed, want.Difficulty, want.Seed, want.Hints)
+	}
+	if !got.Created.Equal(want.Created) || !got.Saved.Equal(want.Saved) {
+		t.Errorf("got created %v, saved %v; want %v, %v", got.Created, got.Saved, want.Created, want.Saved)
+	}
+
+	// The history read back is usable: undoing every move empties the
+	// board down to the givens.
+	for len(got.Board.UndoHistory()) > 0 {
+		got.Board.Undo()
+	}
+	if g := got.Board.Digits(); g != got.Board.Givens() {
+		t.Errorf("undoing every move left %s, want %s", g, got.Board.Givens())
+	}
+}
+
+func TestReadGameError(t *testing.T) {
+	givens := `"` + testPuzzle + `"`
+	entries := `"` + strings.Repeat(".", 81) + `"`
+	tests := []struct {
+		name string
+		// fields replaces the fields of a valid save, by name.
+		fields       map[string]string
+		line, column int
+	}{
+		{"version", map[string]string{"version": "2"}, 2, 13},
+		{"givens", map[string]string{"givens": `"53.x` + testPuzzle[4:] + `"`}, 3, 16},
+		{"entries", map[string]string{"entries": `"..x` + strings.Repeat(".", 78) + `"`}, 4, 16},
+		{"short entries", map[string]string{"entries": `"...."`}, 4, 14},
+		{"both", map[string]string{"entries": `"1` + strings.Repeat(".", 80) + `"`}, 4, 14},
+		{"undo on a given", map[string]string{"undo": `[[{"row": 0, "col": 2, "digit": 0}], [{"row": 0, "col": 0, "digit": 0}]]`}, 5, 10},
+		{"redo on a given", map[string]string{"redo": `[[{"row": 8, "col": 7, "digit": 0}]]`}, 6, 10},
+		{"difficulty", map[string]string{"difficulty": `"Trivial"`}, 7, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := [][2]string{
+				{"version", "1"},
+				{"givens", givens},
+				{"entries", entries},
+				{"undo", "[]"},
+				{"redo", "[]"},
+				{"difficulty", `"Easy"`},
+			}
+			var s strings.Builder
+			s.WriteString("{\n")
+			for i, f := range fields {
+				value := f[1]
+				if v, ok := tt.fields[f[0]]; ok {
+					value = v
+				}
+				// Every field on a line of its own, from line 2.
+				s.WriteString("\t\"" + f[0] + "\": " + value)
+				if i < len(fields)-1 {
+					s.WriteString(",")
+				}
+				s.WriteString("\n")
+			}
+			s.WriteString("}\n")
+
+			_, err := ReadGame(strings.NewReader(s.String()))
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			if perr.Line != tt.line || perr.Column != tt.column {
+				t.Errorf("got line %d, column %d (%v), want line %d, column %d", perr.Line, perr.Column, err, tt.line, tt.column)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package sudoku
+
+import (
+	"context"
+	"math/rand"
+)
+
+// GeneratePuzzle returns a puzzle with exactly one solution. Puzzles
+// generated from the same seed are identical.
+//
+// A random, completely filled grid is generated first, then clues are
+// removed from it in a random order. A clue whose removal gives the
+// puzzle more than one solution is put back, so the resulting puzzle is
+// minimal: removing any of its clues would break uniqueness.
+func GeneratePuzzle(seed int64) Grid {
+	rng := rand.New(rand.NewSource(seed))
+
+	s, _ := newSolver(Grid{})
+	s.limit, s.rng = 1, rng
+	s.search()
+	puzzle := s.solution
+
+	for _, i := range rng.Perm(81) {
+		digit := puzzle[i]
+		puzzle[i] = 0
+		if n, _, _ := CountSolutions(context.Background(), puzzle, 2); n != 1 {
+			puzzle[i] = digit
+		}
+	}
+	return puzzle
+}
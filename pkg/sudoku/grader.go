@@ -0,0 +1,126 @@
+package sudoku
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// Difficulty is the difficulty level of a puzzle.
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+	Expert
+	// Evil puzzles can't be solved by the strategies known to the
+	// grader alone, and need guessing.
+	Evil
+)
+
+var difficultyNames = [...]string{"Easy", "Medium", "Hard", "Expert", "Evil"}
+
+func (d Difficulty) String() string {
+	return difficultyNames[d]
+}
+
+// ParseDifficulty returns the Difficulty named s, ignoring case. ok is
+// false if no Difficulty is named s.
+func ParseDifficulty(s string) (d Difficulty, ok bool) {
+	for i, name := range difficultyNames {
+		if strings.EqualFold(s, name) {
+			return Difficulty(i), true
+		}
+	}
+	return Easy, false
+}
+
+// Difficulties returns the names of every Difficulty, from the easiest
+// to the hardest.
+func Difficulties() []string {
+	return append([]string(nil), difficultyNames[:]...)
+}
+
+// Grade is the rating of a puzzle.
+type Grade struct {
+	// Difficulty is the difficulty of the hardest strategy needed to
+	// solve the puzzle.
+	Difficulty Difficulty
+
+	// Hardest is the name of the hardest strategy needed, or "Guessing"
+	// for Evil puzzles.
+	Hardest string
+
+	// Score sums the score of every strategy application needed to
+	// solve the puzzle. Puzzles of the same difficulty can be compared
+	// with it.
+	Score int
+}
+
+// guessScore is added to the score of a puzzle every time the grader
+// has to guess a digit.
+const guessScore = 100
+
+// GradePuzzle rates puzzle by solving it like a human would: by
+// applying the easiest applicable strategy over and over. When no
+// strategy applies, the grader guesses the digit of a cell from the
+// solution and carries on. puzzle must have a unique solution.
+func GradePuzzle(puzzle Grid) Grade {
+	gr := Grade{Difficulty: Easy, Hardest: strategies[0].name}
+	g := newCandidateGrid(puzzle)
+	var solution Grid
+	for !g.solved() {
+		st := nextStep(g)
+		if st == nil {
+			if solution == (Grid{}) {
+				solution, _ = Solve(puzzle, 1)
+			}
+			gr.Difficulty, gr.Hardest = Evil, "Guessing"
+			gr.Score += guessScore
+			for i, d := range g.digits {
+				if d == 0 {
+					g.place(i, solution[i])
+					break
+				}
+			}
+			continue
+		}
+		if st.strategy.difficulty > gr.Difficulty {
+			gr.Difficulty, gr.Hardest = st.strategy.difficulty, st.strategy.name
+		}
+		gr.Score += st.strategy.score
+		g.apply(st)
+	}
+	return gr
+}
+
+// maxGenerateAttempts bounds the number of puzzles generated by
+// GenerateGradedPuzzle while looking for the requested difficulty.
+const maxGenerateAttempts = 200
+
+// GenerateGradedPuzzle generates puzzles until one of difficulty d is
+// found, and returns it along with its grade. If none is found in
+// maxGenerateAttempts tries, the puzzle closest to d is returned.
+// Puzzles generated from the same seed are identical.
+func GenerateGradedPuzzle(seed int64, d Difficulty) (Grid, Grade) {
+	rng := rand.New(rand.NewSource(seed))
+	var best Grid
+	var bestGrade Grade
+	distance := func(gr Grade) int {
+		if gr.Difficulty > d {
+			return int(gr.Difficulty - d)
+		}
+		return int(d - gr.Difficulty)
+	}
+	for i := 0; i < maxGenerateAttempts; i++ {
+		puzzle := GeneratePuzzle(rng.Int63())
+		gr := GradePuzzle(puzzle)
+		if i == 0 || distance(gr) < distance(bestGrade) {
+			best, bestGrade = puzzle, gr
+		}
+		if gr.Difficulty == d {
+			break
+		}
+	}
+	return best, bestGrade
+}
@@ -0,0 +1,48 @@
+package sudoku
+
+import "strings"
+
+// Grid holds the digit of every cell of a puzzle in row major order. An
+// empty cell is denoted by 0.
+type Grid [81]int
+
+// String returns g as a line of 81 characters, with '.' denoting an
+// empty cell.
+func (g Grid) String() string {
+	var s strings.Builder
+	for _, d := range g {
+		if d == 0 {
+			s.WriteByte('.')
+		} else {
+			s.WriteByte(byte(d) + '0')
+		}
+	}
+	return s.String()
+}
+
+// Count returns the number of cells of g holding digit.
+func (g Grid) Count(digit int) int {
+	n := 0
+	for _, d := range g {
+		if d == digit {
+			n++
+		}
+	}
+	return n
+}
+
+// boxOf returns the index of the 3x3 box containing row r and column
+// c. Boxes are numbered from left to right, top to bottom.
+func boxOf(r, c int) int {
+	return 3*(r/3) + c/3
+}
+
+// IsPeer reports whether the cells at index i and j share a row, column
+// or box. A cell is not a peer of itself.
+func IsPeer(i, j int) bool {
+	if i == j {
+		return false
+	}
+	ri, ci, rj, cj := i/9, i%9, j/9, j%9
+	return ri == rj || ci == cj || boxOf(ri, ci) == boxOf(rj, cj)
+}
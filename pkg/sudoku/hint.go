@@ -0,0 +1,126 @@
+package sudoku
+
+import "fmt"
+
+// Hint is the next step towards solving a Board, explained to the user.
+type Hint struct {
+	// Strategy is the name of the strategy the step is made with, or
+	// "Mistake" and "Missing note" for the hints pointing at mistakes
+	// of the user.
+	Strategy string
+
+	// Placements are the digits the step puts in their cells. The
+	// digit of a placement is 0 when the step clears a wrong entry.
+	Placements []Candidate
+
+	// Eliminations are the candidates the step removes from the notes
+	// of their cells.
+	Eliminations []Candidate
+
+	// Notes are the candidates the step adds to the notes of their
+	// cells.
+	Notes []Candidate
+
+	// Cells are the cells whose contents prove the step.
+	Cells []int
+
+	// Reason explains the step.
+	Reason string
+
+	// candidates are the candidates the step was found with, used to
+	// fill the notes of the cells without any when applying an
+	// elimination.
+	candidates [81]uint16
+}
+
+// String returns the name of the strategy followed by the explanation
+// of h, like "Hidden single: 7 must go in r4c2 because ...".
+func (h *Hint) String() string {
+	return h.Strategy + ": " + h.Reason
+}
+
+// NextHint returns the next step towards solving b, or nil if b is
+// solved or none of the strategies apply. Mistakes of the user come
+// first: entries breaking a constraint or differing from the solution,
+// then notes missing the digit of the solution of their cell. The notes
+// of a cell otherwise narrow its candidates, so the step follows the
+// deductions the user already made.
+func NextHint(b *Board) *Hint {
+	if b.IsSolved() {
+		return nil
+	}
+	if v := Validate(b); v.Conflicts != 0 || v.Wrong != 0 {
+		h := &Hint{Strategy: "Mistake"}
+		for i, invalid := range v.Invalid {
+			if invalid && !b.cells[i].Readonly() {
+				h.Placements = append(h.Placements, Candidate{i, 0})
+				h.Cells = append(h.Cells, i)
+			}
+		}
+		if len(h.Cells) == 1 {
+			h.Reason = fmt.Sprintf("%s doesn't hold the right digit, so it must be cleared.", CellName(h.Cells[0]))
+		} else {
+			h.Reason = fmt.Sprintf("%s don't hold the right digits, so they must be cleared.", cellNames(h.Cells))
+		}
+		return h
+	}
+
+	digits := b.Digits()
+	solution, n := Solve(digits, 2)
+	if n == 0 {
+		return nil
+	}
+	if n == 1 {
+		for i := range b.cells {
+			cell := &b.cells[i]
+			if d := solution[i]; cell.IsEmpty() && cell.Notes() != 0 && !cell.HasNote(d) {
+				return &Hint{
+					Strategy: "Missing note",
+					Notes:    []Candidate{{i, d}},
+					Cells:    []int{i},
+					Reason:   fmt.Sprintf("the notes of %s are missing a digit that can go there.", CellName(i)),
+				}
+			}
+		}
+	}
+
+	g := newCandidateGrid(digits)
+	for i := range b.cells {
+		if notes := uint16(b.cells[i].Notes()); notes != 0 && g.candidates[i] != 0 {
+			g.candidates[i] &= notes
+		}
+	}
+	st := nextStep(g)
+	if st == nil {
+		return nil
+	}
+	return &Hint{
+		Strategy:     st.strategy.name,
+		Placements:   st.placements,
+		Eliminations: st.eliminations,
+		Cells:        st.cells,
+		Reason:       st.reason,
+		candidates:   g.candidates,
+	}
+}
+
+// Apply makes the step of h on b, as a single move. Eliminations from a
+// cell without notes first fill its notes with its candidates.
+func (h *Hint) Apply(b *Board) {
+	b.BeginTransaction()
+	for _, c := range h.Eliminations {
+		cell := &b.cells[c.Cell]
+		notes := cell.Notes()
+		if notes == 0 {
+			notes = Candidates(h.candidates[c.Cell])
+		}
+		b.SetNotesWithUndo(c.Cell/9, c.Cell%9, notes&^(1<<(c.Digit-1)))
+	}
+	for _, c := range h.Notes {
+		b.SetNotesWithUndo(c.Cell/9, c.Cell%9, b.cells[c.Cell].Notes()|1<<(c.Digit-1))
+	}
+	for _, c := range h.Placements {
+		b.SetCellWithUndo(c.Cell/9, c.Cell%9, c.Digit)
+	}
+	b.CommitTransaction()
+}
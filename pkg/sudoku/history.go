@@ -0,0 +1,112 @@
+package sudoku
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WriteHistory writes history to w, one change per line, oldest first.
+// NOTE: empty cell is denoted by '.'.
+// NOTE: notes, if any, follow the digit as a list of digits, e.g.
+// "4 7 . 139".
+// NOTE: the changes of a move with more than one change are enclosed by
+// a line holding '{' and a line holding '}'.
+func WriteHistory(w io.Writer, history []Move) error {
+	bw := bufio.NewWriter(w)
+	for _, move := range history {
+		if len(move) > 1 {
+			bw.WriteString("{\n")
+		}
+		for _, change := range move {
+			c := byte('.')
+			if d := change.Digit; d != 0 {
+				c = byte(d) + '0'
+			}
+			line := []byte{byte(change.Row) + '0', ' ', byte(change.Col) + '0', ' ', c}
+			if change.Notes != 0 {
+				line = append(line, ' ')
+				line = append(line, change.Notes.String()...)
+			}
+			bw.Write(append(line, '\n'))
+		}
+		if len(move) > 1 {
+			bw.WriteString("}\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadHistory reads a history written by WriteHistory from r. Problems
+// with the contents of r are reported as a *ParseError.
+func ReadHistory(r io.Reader) ([]Move, error) {
+	var history []Move
+	// group collects the changes of a multi-change move while inGroup.
+	var group Move
+	inGroup := false
+	s := bufio.NewScanner(r)
+	n := 0
+	// fail returns a ParseError at column of the line last scanned.
+	fail := func(column int, err error) error {
+		return &ParseError{Line: n, Column: column, Err: err}
+	}
+	for s.Scan() {
+		n++
+		line := s.Bytes()
+		switch string(line) {
+		case "{":
+			if inGroup {
+				return nil, fail(1, errors.New("parsing history: nested '{'"))
+			}
+			inGroup = true
+			continue
+		case "}":
+			if !inGroup {
+				return nil, fail(1, errors.New("parsing history: '}' without a matching '{'"))
+			}
+			if len(group) > 0 {
+				history = append(history, group)
+			}
+			group, inGroup = nil, false
+			continue
+		}
+		var notes Candidates
+		if len(line) > 6 && line[5] == ' ' {
+			var ok bool
+			if notes, ok = ParseCandidates(string(line[6:])); !ok {
+				return nil, fail(7, fmt.Errorf("parsing history \"%s\": notes must be in the set [1-9]", line))
+			}
+			line = line[:5]
+		}
+		if len(line) != 5 {
+			return nil, fail(0, fmt.Errorf("parsing history \"%s\": line length must be 5", line))
+		}
+		a, b, c := line[0], line[2], line[4]
+		if a < '0' || a > '8' {
+			return nil, fail(1, fmt.Errorf("parsing history: character is %c, must be in the set [0-8]", a))
+		}
+		if b < '0' || b > '8' {
+			return nil, fail(3, fmt.Errorf("parsing history: character is %c, must be in the set [0-8]", b))
+		}
+		digit := 0
+		if c >= '1' && c <= '9' {
+			digit = int(c - '0')
+		} else if c != '.' {
+			return nil, fail(5, fmt.Errorf("parsing history: character is %c, must be in the set [.1-9]", c))
+		}
+		change := Change{int(a - '0'), int(b - '0'), digit, notes}
+		if inGroup {
+			group = append(group, change)
+		} else {
+			history = append(history, Move{change})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if inGroup {
+		return nil, &ParseError{Err: errors.New("parsing history: '{' without a matching '}'")}
+	}
+	return history, nil
+}
@@ -0,0 +1,148 @@
+package sudoku
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadLegacyGame reads a game saved by older versions, from save, with
+// the undo and redo history read from undo and redo. redo may be nil, as
+// the oldest versions didn't save the redo history.
+//
+// The legacy save holds the puzzle, elapsed time, difficulty, notes, the
+// number of moves and the number of hints, in that order, one per line.
+// The lines following the difficulty are optional.
+// NOTE: It uses '.' to denote empty cell.
+// NOTE: It appends '_' in front of readonly cells
+// NOTE: The notes of every cell are separated by spaces, with '.'
+// denoting no notes.
+// NOTE: The undo and redo history are written by WriteHistory.
+func ReadLegacyGame(save, undo, redo io.Reader) (*Game, error) {
+	g := &Game{Board: &Board{}}
+	b := g.Board
+	scan := bufio.NewScanner(save)
+	// line is the number of the line last scanned.
+	line := 0
+	next := func() bool {
+		line++
+		return scan.Scan()
+	}
+	// fail returns a ParseError at column of the line last scanned.
+	fail := func(column int, err error) error {
+		return &ParseError{File: "save", Line: line, Column: column, Err: err}
+	}
+
+	// puzzle
+	if !next() {
+		return nil, &ParseError{File: "save", Err: errors.New("no puzzle text")}
+	}
+	bytes := scan.Bytes()
+	if l := len(bytes); l < 81 || l > 2*81 {
+		return nil, fail(0, fmt.Errorf("parsing puzzle: invalid length: have %d, want 81 <= length <= 162", l))
+	}
+	j := 0
+	for i := 0; i < len(bytes); i++ {
+		if ch := bytes[i]; ch != '_' && ch != '.' && !(ch >= '0' && ch <= '9') {
+			return nil, fail(i+1, fmt.Errorf("parsing puzzle: character %q must be in the set [_.1-9]", ch))
+		}
+		if j == 81 {
+			return nil, fail(i+1, errors.New("parsing puzzle: more than 81 cells"))
+		}
+		r, c := j/9, j%9
+		if bytes[i] == '_' {
+			b.Cell(r, c).SetReadonly(true)
+			continue
+		}
+		v := 0
+		if bytes[i] != '.' {
+			v = int(bytes[i] - '0')
+		}
+		b.SetCellWithoutUndo(r, c, v)
+		j++
+	}
+	if j != 81 {
+		return nil, fail(0, fmt.Errorf("parsing puzzle: have %d cells, want 81", j))
+	}
+
+	// time
+	if !next() {
+		return nil, &ParseError{File: "save", Err: errors.New("no elapsed time")}
+	}
+	t, err := strconv.Atoi(scan.Text())
+	if err != nil {
+		return nil, fail(1, fmt.Errorf("parsing elapsed time: %w", err))
+	}
+	g.Elapsed = t
+
+	// difficulty
+	if !next() {
+		return nil, &ParseError{File: "save", Err: errors.New("no difficulty text")}
+	}
+	d, ok := ParseDifficulty(scan.Text())
+	if !ok {
+		return nil, fail(1, errors.New("parsing difficulty: difficulty must be either one of: "+strings.Join(difficultyNames[:], ", ")))
+	}
+	g.Difficulty = d
+
+	// notes, optional since saves of older versions don't have them
+	if next() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) != 81 {
+			return nil, fail(0, fmt.Errorf("parsing notes: have %d cells, want 81", len(fields)))
+		}
+		for i, field := range fields {
+			if field == "." {
+				continue
+			}
+			notes, ok := ParseCandidates(field)
+			if !ok {
+				return nil, fail(0, fmt.Errorf("parsing notes: notes of %s must be in the set [.1-9]", CellName(i)))
+			}
+			b.cells[i].SetNotes(notes)
+		}
+	}
+
+	// moves, optional since saves of older versions don't have them
+	if next() {
+		moves, err := strconv.Atoi(scan.Text())
+		if err != nil {
+			return nil, fail(1, fmt.Errorf("parsing moves: %w", err))
+		}
+		b.SetMoves(moves)
+	}
+
+	// hints, optional since saves of older versions don't have them
+	if next() {
+		hints, err := strconv.Atoi(scan.Text())
+		if err != nil {
+			return nil, fail(1, fmt.Errorf("parsing hints: %w", err))
+		}
+		g.Hints = hints
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	if b.undoHistory, err = ReadHistory(undo); err != nil {
+		return nil, inFile("undo", err)
+	}
+	if redo != nil {
+		if b.redoHistory, err = ReadHistory(redo); err != nil {
+			return nil, inFile("redo", err)
+		}
+	}
+	return g, nil
+}
+
+// inFile sets the file of err to file, if err is a *ParseError.
+func inFile(file string, err error) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.File = file
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package sudoku
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// legacyPuzzle is testPuzzle as saved by older versions, with r1c3
+// filled in.
+const legacyPuzzle = "_5_34._7...._6.._1_9_5...._9_8...._6._8..._6..._3_4.._8._3.._1_7..._2..._6._6...._2_8...._4_1_9.._5...._8.._7_9"
+
+func TestReadLegacyGame(t *testing.T) {
+	tests := []struct {
+		name             string
+		save, undo, redo string
+		// noRedo is set if there is no redo file.
+		noRedo bool
+
+		elapsed, moves, hints int
+		difficulty            Difficulty
+		// notes are the notes of r1c4.
+		notes                    Candidates
+		undoHistory, redoHistory []Move
+	}{
+		{
+			name: "full",
+			save: legacyPuzzle + "\n754\nHard\n" +
+				". . . 26" + strings.Repeat(" .", 77) + "\n" +
+				"5\n3\n",
+			undo: "0 2 .\n0 3 . 2\n{\n0 5 .\n1 1 . 27\n}\n",
+			redo: "8 0 3\n",
+
+			elapsed: 754, moves: 5, hints: 3,
+			difficulty: Hard,
+			notes:      Candidates(0).Toggle(2).Toggle(6),
+			undoHistory: []Move{
+				{{0, 2, 0, 0}},
+				{{0, 3, 0, Candidates(0).Toggle(2)}},
+				{{0, 5, 0, 0}, {1, 1, 0, Candidates(0).Toggle(2).Toggle(7)}},
+			},
+			redoHistory: []Move{{{8, 0, 3, 0}}},
+		},
+		{
+			name:   "without optional lines",
+			save:   legacyPuzzle + "\n12\nEasy\n",
+			undo:   "0 2 .\n",
+			noRedo: true,
+
+			elapsed:     12,
+			difficulty:  Easy,
+			undoHistory: []Move{{{0, 2, 0, 0}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var redo io.Reader
+			if !tt.noRedo {
+				redo = strings.NewReader(tt.redo)
+			}
+			g, err := ReadLegacyGame(strings.NewReader(tt.save), strings.NewReader(tt.undo), redo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := g.Board
+			if got, want := b.Givens(), mustParsePuzzle(t, testPuzzle); got != want {
+				t.Errorf("givens: got %s, want %s", got, want)
+			}
+			if d := b.Cell(0, 2).Value(); d != 4 || b.Cell(0, 2).Readonly() {
+				t.Errorf("r1c3: got digit %d, readonly %v; want 4, false", d, b.Cell(0, 2).Readonly())
+			}
+			if n := b.Cell(0, 3).Notes(); n != tt.notes {
+				t.Errorf("notes of r1c4: got %q, want %q", n, tt.notes)
+			}
+			if g.Elapsed != tt.elapsed || g.Difficulty != tt.difficulty || b.Moves() != tt.moves || g.Hints != tt.hints {
+				t.Errorf("got elapsed %d, difficulty %s, moves %d, hints %d; want %d, %s, %d, %d",
+					g.Elapsed, g.Difficulty, b.Moves(), g.Hints, tt.elapsed, tt.difficulty, tt.moves, tt.hints)
+			}
+			if !reflect.DeepEqual(b.UndoHistory(), tt.undoHistory) {
+				t.Errorf("undo history: got %v, want %v", b.UndoHistory(), tt.undoHistory)
+			}
+			if !reflect.DeepEqual(b.RedoHistory(), tt.redoHistory) {
+				t.Errorf("redo history: got %v, want %v", b.RedoHistory(), tt.redoHistory)
+			}
+		})
+	}
+}
+
+func TestReadLegacyGameError(t *testing.T) {
+	tests := []struct {
+		name, save, undo string
+		file             string
+		line, column     int
+	}{
+		{"puzzle", "_5_3x" + legacyPuzzle[5:] + "\n12\nEasy\n", "", "save", 1, 5},
+		{"time", legacyPuzzle + "\n1m\nEasy\n", "", "save", 2, 1},
+		{"difficulty", legacyPuzzle + "\n12\nTrivial\n", "", "save", 3, 1},
+		{"notes", legacyPuzzle + "\n12\nEasy\n. .\n", "", "save", 4, 0},
+		{"undo", legacyPuzzle + "\n12\nEasy\n", "0 2 .\n0 9 1\n", "undo", 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadLegacyGame(strings.NewReader(tt.save), strings.NewReader(tt.undo), nil)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			if perr.File != tt.file || perr.Line != tt.line || perr.Column != tt.column {
+				t.Errorf("got %s line %d, column %d (%v), want %s line %d, column %d",
+					perr.File, perr.Line, perr.Column, err, tt.file, tt.line, tt.column)
+			}
+		})
+	}
+}
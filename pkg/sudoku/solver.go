@@ -0,0 +1,163 @@
+package sudoku
+
+import (
+	"context"
+	"math/bits"
+	"math/rand"
+)
+
+// allCandidates is AllCandidates as the bitmask used by the solvers.
+const allCandidates = uint16(AllCandidates)
+
+// solver is a backtracking sudoku solver. It keeps a bitmask of the
+// digits used in every row, column and box, so candidates of a cell can
+// be computed in constant time, and always branches on the empty cell
+// with the fewest candidates. Cells left with a single candidate are
+// therefore filled before any guessing happens.
+type solver struct {
+	cells             Grid
+	rows, cols, boxes [9]uint16
+	limit, count      int
+	solution          Grid
+
+	// rng, if set, shuffles the order in which the candidates of a
+	// cell are tried. Used to generate random grids.
+	rng *rand.Rand
+
+	// ctx, if set, cancels the search once done, setting err. It is
+	// checked every checkInterval calls of search.
+	ctx   context.Context
+	calls int
+	err   error
+}
+
+// checkInterval is the number of calls of solver.search between checks
+// of its context, which are too slow to do on every call.
+const checkInterval = 1 << 10
+
+// newSolver returns a solver for puzzle, where 0 denotes an empty cell.
+// ok is false if the filled cells of puzzle already break a row, column
+// or box constraint.
+func newSolver(puzzle Grid) (s *solver, ok bool) {
+	s = &solver{}
+	for i, d := range puzzle {
+		if d == 0 {
+			continue
+		}
+		bit := uint16(1) << (d - 1)
+		r, c, b := i/9, i%9, boxOf(i/9, i%9)
+		if (s.rows[r]|s.cols[c]|s.boxes[b])&bit != 0 {
+			return nil, false
+		}
+		s.place(i, d)
+	}
+	return s, true
+}
+
+// candidates returns the candidate set of the cell at index i.
+func (s *solver) candidates(i int) uint16 {
+	r, c := i/9, i%9
+	return allCandidates &^ (s.rows[r] | s.cols[c] | s.boxes[boxOf(r, c)])
+}
+
+func (s *solver) place(i, d int) {
+	bit := uint16(1) << (d - 1)
+	r, c := i/9, i%9
+	s.cells[i] = d
+	s.rows[r] |= bit
+	s.cols[c] |= bit
+	s.boxes[boxOf(r, c)] |= bit
+}
+
+func (s *solver) unplace(i int) {
+	bit := uint16(1) << (s.cells[i] - 1)
+	r, c := i/9, i%9
+	s.cells[i] = 0
+	s.rows[r] &^= bit
+	s.cols[c] &^= bit
+	s.boxes[boxOf(r, c)] &^= bit
+}
+
+// search fills the empty cells of s, counting every solution found
+// until the limit is reached. The first solution is kept in
+// s.solution.
+func (s *solver) search() {
+	if s.ctx != nil {
+		if s.calls++; s.calls%checkInterval == 0 {
+			s.err = s.ctx.Err()
+		}
+		if s.err != nil {
+			return
+		}
+	}
+	best, bestCandidates, bestCount := -1, uint16(0), 10
+	for i, d := range s.cells {
+		if d != 0 {
+			continue
+		}
+		m := s.candidates(i)
+		n := bits.OnesCount16(m)
+		if n == 0 {
+			// dead end
+			return
+		}
+		if n < bestCount {
+			best, bestCandidates, bestCount = i, m, n
+			if n == 1 {
+				break
+			}
+		}
+	}
+	if best == -1 {
+		if s.count == 0 {
+			s.solution = s.cells
+		}
+		s.count++
+		return
+	}
+	digits := make([]int, 0, bestCount)
+	for m := bestCandidates; m != 0; m &= m - 1 {
+		digits = append(digits, bits.TrailingZeros16(m)+1)
+	}
+	if s.rng != nil {
+		s.rng.Shuffle(len(digits), func(i, j int) {
+			digits[i], digits[j] = digits[j], digits[i]
+		})
+	}
+	for _, d := range digits {
+		s.place(best, d)
+		s.search()
+		s.unplace(best)
+		if s.count >= s.limit || s.err != nil {
+			return
+		}
+	}
+}
+
+// Solve searches for the solutions of puzzle. It stops once limit
+// solutions have been found, and returns the number of solutions found
+// along with the first one of them.
+func Solve(puzzle Grid, limit int) (solution Grid, count int) {
+	count, solution, _ = CountSolutions(context.Background(), puzzle, limit)
+	return solution, count
+}
+
+// CountSolutions counts the solutions of puzzle, which may be filled in
+// part or whole, stopping once limit solutions have been found. The
+// witness is the first solution found, if any. A limit of 2 is enough
+// to tell whether puzzle has no, one or many solutions.
+//
+// The search stops early if ctx is done, returning the solutions found
+// so far along with the error of ctx.
+func CountSolutions(ctx context.Context, puzzle Grid, limit int) (count int, witness Grid, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, witness, err
+	}
+	s, ok := newSolver(puzzle)
+	if !ok {
+		return 0, witness, nil
+	}
+	s.limit, s.ctx = limit, ctx
+	s.search()
+	return s.count, s.solution, s.err
+}
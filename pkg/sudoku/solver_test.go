@@ -0,0 +1,119 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// checkSolution fails t if solution isn't a completely filled grid
+// breaking no constraint, holding the digits of puzzle.
+func checkSolution(t *testing.T, puzzle, solution Grid) {
+	t.Helper()
+	if solution.Count(0) != 0 {
+		t.Fatalf("solution %s isn't completely filled", solution)
+	}
+	if _, ok := newSolver(solution); !ok {
+		t.Fatalf("solution %s breaks a constraint", solution)
+	}
+	for i, d := range puzzle {
+		if d != 0 && solution[i] != d {
+			t.Fatalf("solution %s changes %s of puzzle %s", solution, CellName(i), puzzle)
+		}
+	}
+}
+
+func TestCountSolutions(t *testing.T) {
+	tests := []struct {
+		name string
+		// puzzle is "" for the empty grid.
+		puzzle string
+		limit  int
+		count  int
+		// witness is the only solution of puzzle, or "" if puzzle has
+		// none or many.
+		witness string
+	}{
+		{"unique", testPuzzle, 2, 1, testSolution},
+		{"solved", testSolution, 2, 1, testSolution},
+		{"conflict", "535" + testPuzzle[3:], 2, 0, ""},
+		// r3c1 holds 1 in the only solution, 2 breaks no constraint.
+		{"no solution", testPuzzle[:18] + "2" + testPuzzle[19:], 2, 0, ""},
+		// testPuzzle without the givens of r1c1 and r1c2.
+		{"many", "....7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79", 2, 2, ""},
+		{"limit 1", "", 1, 1, ""},
+		{"limit 5", "", 5, 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var puzzle Grid
+			if tt.puzzle != "" {
+				puzzle = mustParsePuzzle(t, tt.puzzle)
+			}
+			count, witness, err := CountSolutions(context.Background(), puzzle, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.count {
+				t.Fatalf("got %d solutions, want %d", count, tt.count)
+			}
+			switch {
+			case tt.witness != "":
+				if witness.String() != tt.witness {
+					t.Errorf("got witness %s, want %s", witness, tt.witness)
+				}
+			case count == 0:
+				if witness != (Grid{}) {
+					t.Errorf("got witness %s, want none", witness)
+				}
+			default:
+				checkSolution(t, puzzle, witness)
+			}
+		})
+	}
+}
+
+func TestCountSolutionsCanceled(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{"canceled", canceled, context.Canceled},
+		{"expired", expired, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The empty grid has far too many solutions to count them
+			// all before ctx is done.
+			start := time.Now()
+			_, _, err := CountSolutions(tt.ctx, Grid{}, 1<<62)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("took %v to stop", d)
+			}
+		})
+	}
+}
+
+func TestGeneratePuzzle(t *testing.T) {
+	for _, seed := range []int64{1, 2, 42, 1 << 40} {
+		p := GeneratePuzzle(seed)
+		if q := GeneratePuzzle(seed); q != p {
+			t.Errorf("seed %d: generated %s, then %s", seed, p, q)
+		}
+		if _, n := Solve(p, 2); n != 1 {
+			t.Errorf("seed %d: puzzle %s has %d solutions, want 1", seed, p, n)
+		}
+	}
+	if GeneratePuzzle(1) == GeneratePuzzle(2) {
+		t.Errorf("seeds 1 and 2 generated the same puzzle")
+	}
+}
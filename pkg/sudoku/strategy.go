@@ -0,0 +1,593 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// units holds the indices of the cells of every row, column and box, in
+// that order.
+var units [27][9]int
+
+// cellUnits holds, for every cell, the index into units of its row,
+// column and box.
+var cellUnits [81][3]int
+
+func init() {
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			units[i][j] = 9*i + j
+			units[9+i][j] = 9*j + i
+			units[18+i][j] = 9*(3*(i/3)+j/3) + 3*(i%3) + j%3
+		}
+	}
+	for u, unit := range units {
+		for _, i := range unit {
+			cellUnits[i][u/9] = u
+		}
+	}
+}
+
+// unitName returns a human readable name of units[u], like "row 4".
+func unitName(u int) string {
+	return fmt.Sprintf("%s %d", [3]string{"row", "column", "box"}[u/9], u%9+1)
+}
+
+// unitNames returns the names of units[u] for every u in us, joined by
+// commas.
+func unitNames(us []int) string {
+	names := make([]string, len(us))
+	for i, u := range us {
+		names[i] = unitName(u)
+	}
+	return strings.Join(names, ", ")
+}
+
+// CellName returns the name of the cell at index i in the rNcM
+// notation, like "r4c2".
+func CellName(i int) string {
+	return fmt.Sprintf("r%dc%d", i/9+1, i%9+1)
+}
+
+// cellNames returns the names of cells joined by commas.
+func cellNames(cells []int) string {
+	names := make([]string, len(cells))
+	for i, cell := range cells {
+		names[i] = CellName(cell)
+	}
+	return strings.Join(names, ", ")
+}
+
+// digitsOf returns the digits of candidate set m in increasing order.
+func digitsOf(m uint16) []int {
+	var digits []int
+	for ; m != 0; m &= m - 1 {
+		digits = append(digits, bits.TrailingZeros16(m)+1)
+	}
+	return digits
+}
+
+// digitNames returns the digits of candidate set m joined by slashes,
+// like "3/7".
+func digitNames(m uint16) string {
+	var s []string
+	for _, d := range digitsOf(m) {
+		s = append(s, fmt.Sprint(d))
+	}
+	return strings.Join(s, "/")
+}
+
+// candidateGrid is a puzzle along with the candidates of its empty
+// cells, used by the human style strategies.
+type candidateGrid struct {
+	digits Grid
+	// candidates is 0 for filled cells.
+	candidates [81]uint16
+}
+
+// newCandidateGrid returns the candidateGrid of puzzle, where 0 denotes
+// an empty cell. The candidates of an empty cell are all the digits
+// not already placed in its row, column or box.
+func newCandidateGrid(puzzle Grid) *candidateGrid {
+	g := &candidateGrid{}
+	for i := range g.candidates {
+		g.candidates[i] = allCandidates
+	}
+	for i, d := range puzzle {
+		if d != 0 {
+			g.place(i, d)
+		}
+	}
+	return g
+}
+
+// place puts digit d in cell i and removes d from the candidates of
+// its peers.
+func (g *candidateGrid) place(i, d int) {
+	g.digits[i] = d
+	g.candidates[i] = 0
+	bit := uint16(1) << (d - 1)
+	for _, u := range cellUnits[i] {
+		for _, j := range units[u] {
+			g.candidates[j] &^= bit
+		}
+	}
+}
+
+// solved reports whether every cell of g is filled.
+func (g *candidateGrid) solved() bool {
+	for _, d := range g.digits {
+		if d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// apply applies the placements and eliminations of s to g.
+func (g *candidateGrid) apply(s *step) {
+	for _, c := range s.eliminations {
+		g.candidates[c.Cell] &^= 1 << (c.Digit - 1)
+	}
+	for _, c := range s.placements {
+		g.place(c.Cell, c.Digit)
+	}
+}
+
+// positions returns the cells of units[u] which have d as a candidate.
+func (g *candidateGrid) positions(u, d int) []int {
+	var cells []int
+	for _, i := range units[u] {
+		if g.candidates[i]&(1<<(d-1)) != 0 {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+// Candidate is a digit in a cell, the cell being an index in row major
+// order.
+type Candidate struct {
+	Cell, Digit int
+}
+
+// step is a single deduction made by a strategy.
+type step struct {
+	strategy *strategy
+
+	// placements are the digits that must go in their cells.
+	placements []Candidate
+
+	// eliminations are the candidates that can be removed.
+	eliminations []Candidate
+
+	// cells are the cells whose candidates prove the deduction.
+	cells []int
+
+	// reason explains the deduction.
+	reason string
+}
+
+// String returns the name of the strategy followed by the explanation
+// of the step, like "Hidden single: 7 must go in r4c2 because ...".
+func (s *step) String() string {
+	return s.strategy.name + ": " + s.reason
+}
+
+// strategy is a technique used by humans to solve sudoku.
+type strategy struct {
+	name string
+
+	// difficulty is the difficulty of the puzzles that need this
+	// strategy.
+	difficulty Difficulty
+
+	// score is added to the score of a puzzle each time the strategy
+	// is applied while grading it.
+	score int
+
+	// find returns the first step of this strategy applicable to g, or
+	// nil.
+	find func(g *candidateGrid) *step
+}
+
+// strategies are ordered from the easiest to the hardest.
+var strategies []*strategy
+
+func init() {
+	strategies = []*strategy{
+		{"Hidden single", Easy, 1, findHiddenSingle},
+		{"Naked single", Easy, 2, findNakedSingle},
+		{"Locked candidates", Medium, 5, findLockedCandidates},
+		{"Naked pair", Medium, 6, findNakedSubset(2)},
+		{"Hidden pair", Medium, 8, findHiddenSubset(2)},
+		{"Naked triple", Hard, 10, findNakedSubset(3)},
+		{"Hidden triple", Hard, 12, findHiddenSubset(3)},
+		{"X-wing", Hard, 15, findFish(2)},
+		{"XY-wing", Hard, 18, findXYWing},
+		{"Swordfish", Expert, 25, findFish(3)},
+		{"XY-chain", Expert, 30, findXYChain},
+	}
+}
+
+// nextStep returns the easiest step applicable to g, or nil if none of
+// the strategies apply.
+func nextStep(g *candidateGrid) *step {
+	for _, s := range strategies {
+		if st := s.find(g); st != nil {
+			st.strategy = s
+			return st
+		}
+	}
+	return nil
+}
+
+func findHiddenSingle(g *candidateGrid) *step {
+	for u := range units {
+		for d := 1; d <= 9; d++ {
+			if cells := g.positions(u, d); len(cells) == 1 {
+				return &step{
+					placements: []Candidate{{cells[0], d}},
+					cells:      cells,
+					reason: fmt.Sprintf(
+						"%d must go in %s because it is the only place left for %d in %s.",
+						d, CellName(cells[0]), d, unitName(u),
+					),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func findNakedSingle(g *candidateGrid) *step {
+	for i, m := range g.candidates {
+		if bits.OnesCount16(m) == 1 {
+			d := bits.TrailingZeros16(m) + 1
+			return &step{
+				placements: []Candidate{{i, d}},
+				cells:      []int{i},
+				reason: fmt.Sprintf(
+					"%d must go in %s because every other digit is already in its row, column or box.",
+					d, CellName(i),
+				),
+			}
+		}
+	}
+	return nil
+}
+
+func findLockedCandidates(g *candidateGrid) *step {
+	for u := range units {
+		for d := 1; d <= 9; d++ {
+			cells := g.positions(u, d)
+			if len(cells) < 2 || len(cells) > 3 {
+				continue
+			}
+			// Find another unit that contains all of cells: a line for
+			// a box (pointing), or a box for a line (claiming).
+			for k := 0; k < 3; k++ {
+				other := cellUnits[cells[0]][k]
+				if other == u || (u < 18) == (other < 18) {
+					continue
+				}
+				shared := true
+				for _, i := range cells[1:] {
+					if cellUnits[i][k] != other {
+						shared = false
+					}
+				}
+				if !shared {
+					continue
+				}
+				var elims []Candidate
+				for _, i := range g.positions(other, d) {
+					if cellUnits[i][u/9] != u {
+						elims = append(elims, Candidate{i, d})
+					}
+				}
+				if len(elims) != 0 {
+					return &step{
+						eliminations: elims,
+						cells:        cells,
+						reason: fmt.Sprintf(
+							"%d in %s can only go in %s, which are all in %s, so %d can be removed from the rest of %s.",
+							d, unitName(u), cellNames(cells), unitName(other), d, unitName(other),
+						),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// combinations calls f with every combination of k items of set until f
+// returns true.
+func combinations(set []int, k int, f func(combination []int) bool) bool {
+	combination := make([]int, 0, k)
+	var rec func(start int) bool
+	rec = func(start int) bool {
+		if len(combination) == k {
+			return f(combination)
+		}
+		for i := start; i < len(set); i++ {
+			combination = append(combination, set[i])
+			if rec(i + 1) {
+				return true
+			}
+			combination = combination[:len(combination)-1]
+		}
+		return false
+	}
+	return rec(0)
+}
+
+// findNakedSubset returns a strategy finding n cells in a unit whose
+// candidates, combined, are exactly n digits.
+func findNakedSubset(n int) func(g *candidateGrid) *step {
+	return func(g *candidateGrid) (st *step) {
+		for u := range units {
+			var cells []int
+			for _, i := range units[u] {
+				if c := bits.OnesCount16(g.candidates[i]); c >= 2 && c <= n {
+					cells = append(cells, i)
+				}
+			}
+			combinations(cells, n, func(subset []int) bool {
+				var m uint16
+				for _, i := range subset {
+					m |= g.candidates[i]
+				}
+				if bits.OnesCount16(m) != n {
+					return false
+				}
+				var elims []Candidate
+				for _, i := range units[u] {
+					if containsInt(subset, i) {
+						continue
+					}
+					for _, d := range digitsOf(g.candidates[i] & m) {
+						elims = append(elims, Candidate{i, d})
+					}
+				}
+				if len(elims) == 0 {
+					return false
+				}
+				st = &step{
+					eliminations: elims,
+					cells:        append([]int(nil), subset...),
+					reason: fmt.Sprintf(
+						"%s can only hold %s, so those digits can be removed from the rest of %s.",
+						cellNames(subset), digitNames(m), unitName(u),
+					),
+				}
+				return true
+			})
+			if st != nil {
+				return st
+			}
+		}
+		return nil
+	}
+}
+
+// findHiddenSubset returns a strategy finding n digits which, in a
+// unit, can only go in the same n cells.
+func findHiddenSubset(n int) func(g *candidateGrid) *step {
+	return func(g *candidateGrid) (st *step) {
+		for u := range units {
+			var digits []int
+			for d := 1; d <= 9; d++ {
+				if c := len(g.positions(u, d)); c >= 2 && c <= n {
+					digits = append(digits, d)
+				}
+			}
+			combinations(digits, n, func(subset []int) bool {
+				var m uint16
+				var cells []int
+				for _, d := range subset {
+					m |= 1 << (d - 1)
+					for _, i := range g.positions(u, d) {
+						if !containsInt(cells, i) {
+							cells = append(cells, i)
+						}
+					}
+				}
+				if len(cells) != n {
+					return false
+				}
+				var elims []Candidate
+				for _, i := range cells {
+					for _, d := range digitsOf(g.candidates[i] &^ m) {
+						elims = append(elims, Candidate{i, d})
+					}
+				}
+				if len(elims) == 0 {
+					return false
+				}
+				st = &step{
+					eliminations: elims,
+					cells:        cells,
+					reason: fmt.Sprintf(
+						"%s can only go in %s in %s, so every other candidate can be removed from those cells.",
+						digitNames(m), cellNames(cells), unitName(u),
+					),
+				}
+				return true
+			})
+			if st != nil {
+				return st
+			}
+		}
+		return nil
+	}
+}
+
+// findFish returns a strategy finding n rows (columns) in which a digit
+// can only go in the same n columns (rows). n = 2 is the X-wing and
+// n = 3 the swordfish.
+func findFish(n int) func(g *candidateGrid) *step {
+	return func(g *candidateGrid) (st *step) {
+		for d := 1; d <= 9; d++ {
+			// base is 0 for rows and 9 for columns; the cover units are
+			// the other kind.
+			for _, base := range []int{0, 9} {
+				cover := 9 - base
+				var lines []int
+				for u := base; u < base+9; u++ {
+					if c := len(g.positions(u, d)); c >= 2 && c <= n {
+						lines = append(lines, u)
+					}
+				}
+				combinations(lines, n, func(subset []int) bool {
+					var cells, covers []int
+					for _, u := range subset {
+						for _, i := range g.positions(u, d) {
+							cells = append(cells, i)
+							if c := cellUnits[i][cover/9]; !containsInt(covers, c) {
+								covers = append(covers, c)
+							}
+						}
+					}
+					if len(covers) != n {
+						return false
+					}
+					var elims []Candidate
+					for _, c := range covers {
+						for _, i := range g.positions(c, d) {
+							if !containsInt(subset, cellUnits[i][base/9]) {
+								elims = append(elims, Candidate{i, d})
+							}
+						}
+					}
+					if len(elims) == 0 {
+						return false
+					}
+					st = &step{
+						eliminations: elims,
+						cells:        cells,
+						reason: fmt.Sprintf(
+							"in %s, %d can only go in %s, so %d can be removed from the rest of %s.",
+							unitNames(subset), d, unitNames(covers), d, unitNames(covers),
+						),
+					}
+					return true
+				})
+				if st != nil {
+					return st
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// commonPeerElims returns the eliminations of digit d from the cells,
+// other than exclude, that are peers of both a and b.
+func (g *candidateGrid) commonPeerElims(a, b, d int, exclude []int) []Candidate {
+	var elims []Candidate
+	for i, m := range g.candidates {
+		if m&(1<<(d-1)) != 0 && !containsInt(exclude, i) && IsPeer(i, a) && IsPeer(i, b) {
+			elims = append(elims, Candidate{i, d})
+		}
+	}
+	return elims
+}
+
+func findXYWing(g *candidateGrid) *step {
+	for pivot, pm := range g.candidates {
+		if bits.OnesCount16(pm) != 2 {
+			continue
+		}
+		for a, am := range g.candidates {
+			if a == pivot || !IsPeer(a, pivot) || bits.OnesCount16(am) != 2 ||
+				bits.OnesCount16(am&pm) != 1 {
+				continue
+			}
+			z := am &^ pm
+			for b, bm := range g.candidates {
+				if b == pivot || b == a || !IsPeer(b, pivot) || bm != (pm&^am)|z {
+					continue
+				}
+				d := bits.TrailingZeros16(z) + 1
+				elims := g.commonPeerElims(a, b, d, []int{pivot, a, b})
+				if len(elims) == 0 {
+					continue
+				}
+				return &step{
+					eliminations: elims,
+					cells:        []int{pivot, a, b},
+					reason: fmt.Sprintf(
+						"%s is %s, and either way one of %s and %s must be %d, so %d can be removed from the cells seeing both of them.",
+						CellName(pivot), strings.Replace(digitNames(pm), "/", " or ", 1), CellName(a), CellName(b), d, d,
+					),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// maxXYChainLength bounds the number of cells in an XY-chain to keep the
+// search fast.
+const maxXYChainLength = 8
+
+func findXYChain(g *candidateGrid) *step {
+	var chain []int
+	// rec extends chain, whose last cell is forced to be digit on if
+	// the first cell is not digit d.
+	var rec func(d, on int) *step
+	rec = func(d, on int) *step {
+		last := chain[len(chain)-1]
+		if on == d && len(chain) >= 3 {
+			if elims := g.commonPeerElims(chain[0], last, d, chain); len(elims) != 0 {
+				return &step{
+					eliminations: elims,
+					cells:        append([]int(nil), chain...),
+					reason: fmt.Sprintf(
+						"along the chain %s, if %s isn't %d then %s is, so %d can be removed from the cells seeing both ends.",
+						cellNames(chain), CellName(chain[0]), d, CellName(last), d,
+					),
+				}
+			}
+		}
+		if len(chain) == maxXYChainLength {
+			return nil
+		}
+		bit := uint16(1) << (on - 1)
+		for next, m := range g.candidates {
+			if bits.OnesCount16(m) != 2 || m&bit == 0 || !IsPeer(next, last) || containsInt(chain, next) {
+				continue
+			}
+			chain = append(chain, next)
+			if st := rec(d, bits.TrailingZeros16(m&^bit)+1); st != nil {
+				return st
+			}
+			chain = chain[:len(chain)-1]
+		}
+		return nil
+	}
+	for start, m := range g.candidates {
+		if bits.OnesCount16(m) != 2 {
+			continue
+		}
+		for _, d := range digitsOf(m) {
+			chain = []int{start}
+			if st := rec(d, bits.TrailingZeros16(m&^(1<<(d-1)))+1); st != nil {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
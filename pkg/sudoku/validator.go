@@ -0,0 +1,113 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validation is the outcome of validating a Board.
+type Validation struct {
+	// Conflicts is the number of pairs of cells that share a row,
+	// column or box and hold the same digit.
+	Conflicts int
+
+	// Wrong is the number of entries that differ from the solution of
+	// the puzzle. It is only computed when Unique is true.
+	Wrong int
+
+	// Unique reports whether the givens of the puzzle have exactly
+	// one solution.
+	Unique bool
+
+	// Invalid marks every cell that is part of a conflict or holds a
+	// wrong digit, in row major order.
+	Invalid [81]bool
+}
+
+// Conflicts returns the cells of g that share a row, column or box with
+// another cell holding the same digit, in row major order, along with
+// the number of such pairs of cells.
+func Conflicts(g Grid) (cells [81]bool, pairs int) {
+	for i := 0; i < 81; i++ {
+		for j := i + 1; j < 81; j++ {
+			if g[i] != 0 && g[i] == g[j] && IsPeer(i, j) {
+				pairs++
+				cells[i], cells[j] = true, true
+			}
+		}
+	}
+	return cells, pairs
+}
+
+// Validate checks b against the row, column and box constraints, and
+// compares the entries of b with the solution of its givens.
+func Validate(b *Board) Validation {
+	var v Validation
+	v.Invalid, v.Conflicts = Conflicts(b.Digits())
+
+	n, solution, _ := CountSolutions(context.Background(), b.Givens(), 2)
+	if v.Unique = n == 1; v.Unique {
+		for i := range b.cells {
+			cell := &b.cells[i]
+			if !cell.Readonly() && !cell.IsEmpty() && cell.Value() != solution[i] {
+				v.Wrong++
+				v.Invalid[i] = true
+			}
+		}
+	}
+	return v
+}
+
+// String returns a summary of v suitable to be shown to the user, for
+// example, "3 conflicts, 2 wrong digits".
+func (v Validation) String() string {
+	if !v.Unique {
+		if v.Conflicts == 0 {
+			return "No conflicts, but the givens of this puzzle don't have a unique solution to compare entries against."
+		}
+		return plural(v.Conflicts, "conflict") + ". The givens of this puzzle don't have a unique solution to compare entries against."
+	}
+	if v.Conflicts == 0 && v.Wrong == 0 {
+		return "No mistakes so far."
+	}
+	var s []string
+	if v.Conflicts != 0 {
+		s = append(s, plural(v.Conflicts, "conflict"))
+	}
+	if v.Wrong != 0 {
+		s = append(s, plural(v.Wrong, "wrong digit"))
+	}
+	return strings.Join(s, ", ")
+}
+
+// plural returns n followed by noun, pluralised by appending an 's'
+// when n is not 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// CheckPuzzle returns an error unless the givens of puzzle are free of
+// conflicts and have exactly one solution.
+func CheckPuzzle(puzzle Grid) error {
+	if cells, pairs := Conflicts(puzzle); pairs > 0 {
+		var names []string
+		for i, conflict := range cells {
+			if conflict {
+				names = append(names, CellName(i))
+			}
+		}
+		return fmt.Errorf("the givens of %s conflict", strings.Join(names, ", "))
+	}
+	switch n, _, _ := CountSolutions(context.Background(), puzzle, 2); n {
+	case 0:
+		return errors.New("the puzzle has no solution")
+	case 2:
+		return errors.New("the puzzle has more than one solution")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// saveBackups is the number of previous saves kept as backups.
+const saveBackups = 3
+
+// writeFile writes the file at path with write, atomically: the contents
+// are written to a temporary file next to path, flushed to disk, and only
+// then renamed to path. If anything fails, the file at path is left as it
+// was.
+func writeFile(path string, write func(w io.Writer) error) (err error) {
+	dir, name := filepath.Split(path)
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0640); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Flush the rename too, so that it survives a crash. Not every
+	// system supports syncing a directory, which is fine.
+	if d, err := os.Open(filepath.Clean(dir)); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// backupPath returns the path of the ith backup of the file at path,
+// counting from 1 for the newest.
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// rotateBackups keeps the file at path as its newest backup, shifting
+// the older backups along and dropping the oldest, so that at most n are
+// kept. The file at path is left in place. Nothing is done if there is no
+// file at path.
+func rotateBackups(path string, n int) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	for i := n - 1; i >= 1; i-- {
+		err := os.Rename(backupPath(path, i), backupPath(path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	newest := backupPath(path, 1)
+	if err := os.Remove(newest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// A hard link keeps the file at path in place, so that there is a
+	// save even if the game is never saved after this. Not every file
+	// system supports hard links, copy the file on those.
+	if err := os.Link(path, newest); err == nil {
+		return nil
+	}
+	return copyFile(path, newest)
+}
+
+// copyFile copies the file at src to dst, atomically.
+func copyFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeFile(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, f)
+		return err
+	})
+}
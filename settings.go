@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Settings are the preferences of the user, kept across sessions.
+type Settings struct {
+	// HighlightPeers tints the row, column and box of the selected
+	// cell.
+	HighlightPeers bool
+
+	// HighlightDigits emphasizes every other cell holding the digit of
+	// the selected cell.
+	HighlightDigits bool
+
+	// LiveConflicts marks the cells holding the same digit as another
+	// cell of their row, column or box while typing.
+	LiveConflicts bool
+}
+
+// The settings used within the application.
+var settings = Settings{
+	HighlightPeers:  true,
+	HighlightDigits: true,
+	LiveConflicts:   true,
+}
+
+// setting is a toggle of Settings.
+type setting struct {
+	// name identifies the setting in the settings file.
+	name string
+
+	// label describes the setting in the settings modal.
+	label string
+
+	value *bool
+}
+
+// toggles returns the settings of s, in the order they are shown in
+// the settings modal.
+func (s *Settings) toggles() []setting {
+	return []setting{
+		{"highlight-peers", "Highlight peers", &s.HighlightPeers},
+		{"highlight-digits", "Highlight digits", &s.HighlightDigits},
+		{"live-conflicts", "Show conflicts", &s.LiveConflicts},
+	}
+}
+
+// ReadSettings reads settings written by WriteSettings from r into s.
+// Settings missing from r keep their value, and unknown settings are
+// ignored so that files of newer versions can be read.
+func (s *Settings) ReadSettings(r io.Reader) error {
+	toggles := s.toggles()
+	scan := bufio.NewScanner(r)
+	for line := 1; scan.Scan(); line++ {
+		fields := strings.Fields(scan.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") {
+			return fmt.Errorf("parsing settings: line %d: want \"<name> on|off\"", line)
+		}
+		for _, t := range toggles {
+			if t.name == fields[0] {
+				*t.value = fields[1] == "on"
+			}
+		}
+	}
+	return scan.Err()
+}
+
+// WriteSettings writes s to w, one setting per line, like
+// "highlight-peers on".
+func (s *Settings) WriteSettings(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range s.toggles() {
+		value := "off"
+		if *t.value {
+			value = "on"
+		}
+		fmt.Fprintln(bw, t.name, value)
+	}
+	return bw.Flush()
+}
+
+// toggleLabel returns the label of the button of t in the settings
+// modal, like "Highlight digits: on".
+func toggleLabel(t setting) string {
+	if *t.value {
+		return t.label + ": on"
+	}
+	return t.label + ": off"
+}
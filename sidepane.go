@@ -45,6 +45,12 @@ func (b *button) GetLabel() string {
 	return string([]rune{b.icon, ' '}) + b.text
 }
 
+// SetText sets the text that appears on the label of b.
+func (b *button) SetText(text string) *button {
+	b.text = text
+	return b
+}
+
 // SetSelectedFunc sets f as the optional handler to fire when b is
 // selected.
 func (b *button) SetSelectedFunc(f func()) *button {
@@ -113,6 +119,23 @@ func (b *button) MouseHandler() func(tview.MouseAction, *tcell.EventMouse, func(
 	})
 }
 
+// Indices of the buttons of a Sidepane, in the order they appear.
+const (
+	newGameButton = iota
+	loadGameButton
+	exportButton
+	undoButton
+	redoButton
+	hintButton
+	validateButton
+	solveButton
+	resetButton
+	pauseButton
+	themeButton
+	accentButton
+	settingsButton
+)
+
 type Sidepane struct {
 	*tview.Flex
 }
@@ -127,16 +150,23 @@ func NewSidepane() *Sidepane {
 
 	s.SetBorderPadding(1, 1, 1, 1)
 
-	for _, item := range [6]struct {
+	for _, item := range [13]struct {
 		icon  rune
 		label string
 	}{
+		{'', "New game"},
+		{'', "Load game"},
+		{'', "Export"},
 		{'', "Undo"},
+		{'', "Redo"},
+		{'', "Hint"},
 		{'', "Validate"},
 		{'', "Solve"},
 		{'', "Reset grid"},
+		{'', "Pause"},
 		{'', "Switch theme"},
 		{'', "Change Accent"},
+		{'', "Settings"},
 	} {
 		s.AddItem(newButton(item.icon, item.label), 3, 1, false)
 	}
@@ -144,6 +174,23 @@ func NewSidepane() *Sidepane {
 	return s
 }
 
+// Draw draws s onto the screen. The buttons are drawn without borders,
+// a row each, if s is too short to show all of them with their borders.
+func (s *Sidepane) Draw(screen tcell.Screen) {
+	_, _, _, height := s.GetInnerRect()
+	compact := height < 3*s.GetItemCount()
+	for i := 0; i < s.GetItemCount(); i++ {
+		b := s.GetButton(i)
+		b.SetBorder(!compact)
+		size := 3
+		if compact {
+			size = 1
+		}
+		s.ResizeItem(b, size, 1)
+	}
+	s.Flex.Draw(screen)
+}
+
 func (s *Sidepane) GetButton(index int) *button {
 	return s.GetItem(index).(*button)
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ValenTheRed/sudoku/pkg/sudoku"
+)
+
+// saveSlot is a game saved in a save slot.
+type saveSlot struct {
+	name string
+
+	// game is the saved game, or nil if it couldn't be loaded with err.
+	game *sudoku.Game
+	err  error
+}
+
+// slotPath returns the path of the file of save slot name.
+func slotPath(name string) string {
+	return filepath.Join(savesdir, name+".json")
+}
+
+// checkSlotName returns an error if name can't name a save slot.
+func checkSlotName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid save slot name %q", name)
+	}
+	return nil
+}
+
+// slotExists reports whether save slot name holds a game.
+func slotExists(name string) bool {
+	_, err := os.Stat(slotPath(name))
+	return !os.IsNotExist(err)
+}
+
+// listSlots returns every save slot, the most recently played first.
+func listSlots() ([]saveSlot, error) {
+	entries, err := os.ReadDir(savesdir)
+	if err != nil {
+		return nil, err
+	}
+	var slots []saveSlot
+	played := map[string]int64{}
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".json")
+		if e.IsDir() || name == e.Name() || checkSlotName(name) != nil {
+			continue
+		}
+		slot := saveSlot{name: name}
+		slot.game, slot.err = loadGame(slotPath(name))
+		if info, err := e.Info(); err == nil {
+			played[name] = info.ModTime().UnixNano()
+		}
+		slots = append(slots, slot)
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		return played[slots[i].name] > played[slots[j].name]
+	})
+	return slots, nil
+}
+
+// newSlotName returns the name of the first unused numbered save slot.
+func newSlotName() string {
+	for n := 1; ; n++ {
+		if name := strconv.Itoa(n); !slotExists(name) {
+			return name
+		}
+	}
+}
+
+// describeGame returns the difficulty, the time spent, how much of the
+// grid is filled, and when game was last played, on a line.
+func describeGame(game *sudoku.Game) string {
+	filled := 81 - game.Board.Digits().Count(0)
+	s := fmt.Sprintf("%s · %s · %d%% filled", game.Difficulty, second(game.Elapsed), filled*100/81)
+	if game.Board.IsSolved() {
+		s = fmt.Sprintf("%s · %s · solved", game.Difficulty, second(game.Elapsed))
+	}
+	if !game.Saved.IsZero() {
+		s += " · " + game.Saved.Local().Format("Jan 2 2006 15:04")
+	}
+	return s
+}
+
+// migrateSave moves the saves of older versions to new save slots: the
+// save of versions before save slots, along with its backups, and the
+// save, undo and redo files of versions before the JSON save format. A
+// legacy save which can't be loaded is left as it is, so that -continue
+// still reports it.
+func migrateSave() error {
+	if _, err := os.Stat(singleSavepath); !os.IsNotExist(err) {
+		name := newSlotName()
+		for i := saveBackups; i >= 1; i-- {
+			err := os.Rename(backupPath(singleSavepath, i), backupPath(slotPath(name), i))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(singleSavepath, slotPath(name)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(legacySavepath); os.IsNotExist(err) {
+		return nil
+	}
+	game, err := loadLegacyGame()
+	if err != nil {
+		return nil
+	}
+	if err := writeFile(slotPath(newSlotName()), game.Write); err != nil {
+		return err
+	}
+	for _, p := range []string{legacySavepath, undopath, redopath} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,98 +1,75 @@
 package main
 
 import (
-	"bufio"
-	"log"
-	"os"
-
+	"github.com/ValenTheRed/sudoku/pkg/sudoku"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
-type SudokuCell struct {
-	value    byte
-	readonly bool
-}
-
-// NewSudokuCell returns a new, modifiable SudokuCell.
-func NewSudokuCell(digit int) *SudokuCell {
-	return (&SudokuCell{}).SetValue(digit)
-}
-
-// NewSudokuCell returns a new, readonly SudokuCell.
-func NewReadonlySudokuCell(digit int) *SudokuCell {
-	return NewSudokuCell(digit).SetReadonly(true)
-}
+// SudokuGrid is the view of a sudoku.Board. It adds the selection of a
+// cell and the state of the user interface to the board.
+type SudokuGrid struct {
+	*tview.Box
+	*sudoku.Board
+	selectedRow, selectedColumn int
 
-// Value returns the digit at c.
-func (c *SudokuCell) Value() int {
-	if c.value == ' ' {
-		return 0
-	}
-	return int(c.value - '0')
-}
+	// locked set to true ignores the input of the user, used once the
+	// puzzle is completed.
+	locked bool
 
-func (c *SudokuCell) SetValue(digit int) *SudokuCell {
-	if digit == 0 {
-		c.value = ' '
-	} else {
-		c.value = byte(digit) + '0'
-	}
-	return c
-}
+	// paused set to true hides the contents of the grid.
+	paused bool
 
-func (c *SudokuCell) Readonly() bool {
-	return c.readonly
-}
+	// notesMode set to true makes digit input toggle the notes of a
+	// cell instead of setting its value.
+	notesMode bool
 
-func (c *SudokuCell) SetReadonly(v bool) *SudokuCell {
-	c.readonly = v
-	return c
-}
+	// invalid marks the cells reported by the last validation. The
+	// marks are cleared on the next edit.
+	invalid [81]bool
 
-func (c *SudokuCell) Rune() rune {
-	return rune(c.value)
-}
+	// hint is the hint shown on the grid, cleared on the next edit.
+	hint *sudoku.Hint
 
-func (c *SudokuCell) IsEmpty() bool {
-	return c.value == ' '
-}
+	// conflicts marks the cells holding the same digit as another cell
+	// of their row, column or box, kept up to date with every edit.
+	conflicts [81]bool
 
-type undoItem struct {
-	row, col, digit byte
+	// changed is called after every edit of the board.
+	changed func()
 }
 
-type SudokuGrid struct {
-	*tview.Box
-	selectedRow, selectedColumn int
-	contents                    [81]*SudokuCell
-	undoHistory                 []undoItem
+// NewSudokuGrid returns a new SudokuGrid showing board.
+func NewSudokuGrid(board *sudoku.Board) *SudokuGrid {
+	g := &SudokuGrid{
+		Box:   tview.NewBox(),
+		Board: board,
+	}
+	board.SetChangedFunc(func() {
+		g.invalid = [81]bool{}
+		g.hint = nil
+		g.conflicts, _ = sudoku.Conflicts(g.Digits())
+		if g.changed != nil {
+			g.changed()
+		}
+	})
+	g.conflicts, _ = sudoku.Conflicts(board.Digits())
+	return g
 }
 
-// NewSudokuGrid returns a new SudokuGrid.
-func NewSudokuGrid() *SudokuGrid {
-	var contents [81]*SudokuCell
-	for r := 0; r < 9; r++ {
-		for c := 0; c < 9; c++ {
-			contents[9*r+c] = NewSudokuCell(0)
-		}
-	}
-	return &SudokuGrid{
-		Box:      tview.NewBox(),
-		contents: contents,
-	}
+// SetChangedFunc sets the handler which is called after every edit of
+// the board, undos and redos included, once g is up to date with it.
+func (g *SudokuGrid) SetChangedFunc(handler func()) *SudokuGrid {
+	g.changed = handler
+	return g
 }
 
-// ClearCells clears all non-readonly cells and the undo history.
+// ClearCells clears the value and notes of all non-readonly cells,
+// unless g is locked. It can be undone in a single step.
 func (g *SudokuGrid) ClearCells() *SudokuGrid {
-	for r := 0; r < 9; r++ {
-		for c := 0; c < 9; c++ {
-			if !g.GetCell(r, c).Readonly() {
-				g.SetCellWithUndo(r, c, 0)
-			}
-		}
+	if !g.locked {
+		g.Board.ClearCells()
 	}
-	g.undoHistory = nil
 	return g
 }
 
@@ -107,90 +84,92 @@ func (g *SudokuGrid) SelectedCell() (int, int) {
 	return g.selectedRow, g.selectedColumn
 }
 
-// GetCell returns the cell at row r and column c.
-func (g *SudokuGrid) GetCell(r, c int) *SudokuCell {
-	return g.contents[9*r+c]
+// SetLocked sets whether g ignores the input of the user: Input, Undo,
+// Redo and ClearCells do nothing while g is locked.
+func (g *SudokuGrid) SetLocked(v bool) *SudokuGrid {
+	g.locked = v
+	return g
+}
+
+// Locked reports whether g ignores the input of the user.
+func (g *SudokuGrid) Locked() bool {
+	return g.locked
 }
 
-// SetCellWithUndo sets the value of cell at row r and column c with the
-// value digit. It doesn't store the previous value of the cell in it's
-// undo history.
-func (g *SudokuGrid) SetCellWithoutUndo(r, c, digit int) *SudokuGrid {
-	g.GetCell(r, c).SetValue(digit)
+// SetPaused sets whether g is paused. A paused grid hides its contents,
+// so the puzzle can't be studied while the clock is stopped, and
+// ignores digit input.
+func (g *SudokuGrid) SetPaused(v bool) *SudokuGrid {
+	g.paused = v
 	return g
 }
 
-// SetCellWithUndo sets the value of cell at row r and column c with the
-// value digit. It also stores the previous value of the cell in it's
-// undo history.
-func (g *SudokuGrid) SetCellWithUndo(r, c, digit int) *SudokuGrid {
-	cell := g.GetCell(r, c)
-	if digit == cell.Value() {
-		return g
-	}
-	g.undoHistory = append(g.undoHistory, undoItem{
-		byte(r), byte(c), byte(cell.Value()),
-	})
-	cell.SetValue(digit)
+// Paused reports whether g is paused.
+func (g *SudokuGrid) Paused() bool {
+	return g.paused
+}
+
+// SetNotesMode sets whether digit input toggles the notes of the
+// selected cell, instead of setting its value.
+func (g *SudokuGrid) SetNotesMode(v bool) *SudokuGrid {
+	g.notesMode = v
+	return g
+}
+
+// NotesMode reports whether digit input toggles notes.
+func (g *SudokuGrid) NotesMode() bool {
+	return g.notesMode
+}
+
+// SetInvalidCells marks the cells for which invalid is true, in row
+// major order, as invalid until the next edit.
+func (g *SudokuGrid) SetInvalidCells(invalid [81]bool) *SudokuGrid {
+	g.invalid = invalid
+	return g
+}
+
+// SetHint highlights the cells involved in hint until the next edit.
+// nil removes the highlight.
+func (g *SudokuGrid) SetHint(hint *sudoku.Hint) *SudokuGrid {
+	g.hint = hint
 	return g
 }
 
-// Undo undos the last move.
+// Hint returns the hint highlighted on g, or nil.
+func (g *SudokuGrid) Hint() *sudoku.Hint {
+	return g.hint
+}
+
+// Undo undos the last move, unless g is locked or paused.
 func (g *SudokuGrid) Undo() *SudokuGrid {
-	if len(g.undoHistory) > 0 {
-		item := g.undoHistory[len(g.undoHistory)-1]
-		g.undoHistory = g.undoHistory[:len(g.undoHistory)-1]
-		g.SetCellWithoutUndo(
-			int(item.row),
-			int(item.col),
-			int(item.digit),
-		)
+	if !g.locked && !g.paused {
+		g.Board.Undo()
 	}
 	return g
 }
 
-// FlushUndoHistoryToFile writes the entire undo history to file and
-// resets the history.
-// NOTE: empty cell is denoted by '.'.
-func (g *SudokuGrid) FlushUndoHistoryToFile(file *os.File) *SudokuGrid {
-	for _, item := range g.undoHistory {
-		a := item.row + '0'
-		b := item.col + '0'
-		c := byte('.')
-		if d := item.digit; d != 0 {
-			c = d + '0'
-		}
-		file.Write([]byte{a, ' ', b, ' ', c, '\n'})
+// Redo redos the last undone move, unless g is locked or paused.
+func (g *SudokuGrid) Redo() *SudokuGrid {
+	if !g.locked && !g.paused {
+		g.Board.Redo()
 	}
-	g.undoHistory = nil
 	return g
 }
 
-// ReadUndoHistoryFromFile reads file and appends it's undo history to
-// g.
-func (g *SudokuGrid) ReadUndoHistoryFromFile(file *os.File) *SudokuGrid {
-	for s := bufio.NewScanner(file); s.Scan(); {
-		line := s.Bytes()
-		if len(line) != 5 {
-			log.Fatalf("ReadUndoHistoryFromFile: parsing undo \"%s\": line length must be 5\n", line)
-		}
-		a, b, c := line[0], line[2], line[4]
-		if a < '0' || a > '9' {
-			log.Fatalf("ReadUndoHistoryFromFile: parsing undo: first character is %c, must be in the set [1-9]", a)
-		}
-		a -= '0'
-		if b < '0' || b > '9' {
-			log.Fatalf("ReadUndoHistoryFromFile: parsing undo: second character is %c, must be in the set [1-9]", b)
-		}
-		b -= '0'
-		if c == '.' {
-			c = 0
-		} else if c >= '0' && c <= '9' {
-			c = c - '0'
-		} else {
-			log.Fatalf("ReadUndoHistoryFromFile: parsing undo: third character is %c, must be in the set [.1-9]", b)
-		}
-		g.undoHistory = append(g.undoHistory, undoItem{a, b, c})
+// Input enters digit in the selected cell, unless the cell is
+// readonly or g is locked. In notes mode, digit is toggled in the notes
+// of the cell instead, and 0 clears them.
+func (g *SudokuGrid) Input(digit int) *SudokuGrid {
+	r, c := g.SelectedCell()
+	cell := g.Cell(r, c)
+	switch {
+	case cell.Readonly(), g.locked, g.paused:
+	case !g.notesMode:
+		g.SetCellWithUndo(r, c, digit)
+	case digit == 0:
+		g.SetNotesWithUndo(r, c, 0)
+	case cell.IsEmpty():
+		g.ToggleNoteWithUndo(r, c, digit)
 	}
 	return g
 }
@@ -227,10 +206,7 @@ func (g *SudokuGrid) InputHandler() func(event *tcell.EventKey, setFocus func(p
 			case 'l':
 				right()
 			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-				cell := g.GetCell(g.selectedRow, g.selectedColumn)
-				if !cell.Readonly() {
-					g.SetCellWithUndo(g.selectedRow, g.selectedColumn, int(r-'0'))
-				}
+				g.Input(int(r - '0'))
 			}
 		case tcell.KeyDown:
 			down()
@@ -253,8 +229,51 @@ const (
 	SudokuGridRowHeight = 2
 	// cell horizontal length = len(' ' + '<number>' ' ' + '|') = 4
 	SudokuGridColumnWidth = 4
+
+	// The larger layout, used when there is enough room, draws notes
+	// as a 3x3 block of digits inside a cell.
+	// cell vertical length = len('123' + '456' + '789' + '-') = 4
+	SudokuGridNotesRowHeight = 4
 )
 
+// rowHeight returns the row height of the layout g is drawn with: the
+// larger SudokuGridNotesRowHeight if g is tall enough, or
+// SudokuGridRowHeight.
+func (g *SudokuGrid) rowHeight() int {
+	_, _, _, height := g.Box.GetInnerRect()
+	if height >= 9*SudokuGridNotesRowHeight-1 {
+		return SudokuGridNotesRowHeight
+	}
+	return SudokuGridRowHeight
+}
+
+// cellRune returns the rune drawn at line and column x inside the
+// content area of cell c, for a layout of row height rh.
+func cellRune(c *sudoku.Cell, line, x, rh int) rune {
+	notes := c.Notes()
+	switch {
+	case !c.IsEmpty() || notes == 0:
+		if x == 1 && line == (rh-1)/2 {
+			return c.Rune()
+		}
+	case rh == SudokuGridNotesRowHeight:
+		if d := 3*line + x + 1; c.HasNote(d) {
+			return rune(d) + '0'
+		}
+	default:
+		// Not enough room for every note, so draw as many as fit,
+		// marking the rest with a '+'.
+		digits := []byte(notes.String())
+		if len(digits) > 3 {
+			digits = append(digits[:2], '+')
+		}
+		if x < len(digits) {
+			return rune(digits[x])
+		}
+	}
+	return ' '
+}
+
 // Draw draws the sudoku grid onto the screen.
 func (g *SudokuGrid) Draw(screen tcell.Screen) {
 	const (
@@ -272,34 +291,90 @@ func (g *SudokuGrid) Draw(screen tcell.Screen) {
 	g.Box.SetBackgroundColor(ColorSchemes[Theme]["background"])
 	g.Box.DrawForSubclass(screen, g)
 	X, Y := g.centerCoordinates()
+	rh := g.rowHeight()
 
 	heavyBorderStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme][Accent]).Background(ColorSchemes[Theme]["background"])
 	lightBorderStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme]["uiSurface"]).Background(ColorSchemes[Theme]["background"])
 	cellStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme]["foreground"]).Background(ColorSchemes[Theme]["background"])
+	notesStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme][Accent]).Background(ColorSchemes[Theme]["background"])
 	readonlyStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme]["foreground"]).Background(ColorSchemes[Theme][Accent])
+	invalidStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme]["white"]).Background(ColorSchemes[Theme]["red"])
+	hintStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme]["black"]).Background(ColorSchemes[Theme]["yellow"])
+	hintTargetStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme]["black"]).Background(ColorSchemes[Theme]["green"])
+
+	sameDigitStyle := tcell.StyleDefault.Foreground(ColorSchemes[Theme]["foreground"]).Background(colorBlend(ColorSchemes[Theme][Accent], ColorSchemes[Theme]["background"], 50)).Bold(true)
+
+	// selected is the index of the selected cell, and selectedDigit
+	// its digit, highlighted in the other cells holding it.
+	selected := 9*g.selectedRow + g.selectedColumn
+	selectedDigit := g.Cell(g.selectedRow, g.selectedColumn).Value()
+
+	// hinted marks the cells proving the step of the hint, and
+	// targeted the cells changed by it.
+	var hinted, targeted [81]bool
+	if h := g.hint; h != nil {
+		for _, i := range h.Cells {
+			hinted[i] = true
+		}
+		for _, cs := range [][]sudoku.Candidate{h.Placements, h.Eliminations, h.Notes} {
+			for _, c := range cs {
+				targeted[c.Cell] = true
+			}
+		}
+	}
 
-	// helper function to draw i-th cell at row y and column x.
-	drawCell := func(c *SudokuCell, style func(tcell.Style) tcell.Style, x, y int) {
-		if c.Readonly() {
-			screen.SetContent(X+x, Y+y, c.Rune(), nil, style(readonlyStyle))
-		} else {
-			screen.SetContent(X+x, Y+y, c.Rune(), nil, style(cellStyle))
+	// helper function to draw the cell at row r and column c, which
+	// occupies the screen at row y and column x.
+	drawCell := func(r, c, x, y int) {
+		if g.paused {
+			// Draw every cell alike, even the readonly ones, so that
+			// nothing of the puzzle shows.
+			screen.SetContent(X+x, Y+y, ' ', nil, cellStyle)
+			return
 		}
+		i, cell := 9*r+c, g.Cell(r, c)
+		style := cellStyle
+		switch {
+		case g.invalid[i], settings.LiveConflicts && g.conflicts[i]:
+			style = invalidStyle
+		case targeted[i]:
+			style = hintTargetStyle
+		case hinted[i]:
+			style = hintStyle
+		case settings.HighlightDigits && i != selected && !cell.IsEmpty() && cell.Value() == selectedDigit:
+			style = sameDigitStyle
+			if cell.Readonly() {
+				style = readonlyStyle.Bold(true).Underline(true)
+			}
+		case cell.Readonly():
+			style = readonlyStyle
+		case cell.IsEmpty():
+			style = notesStyle
+		}
+		if settings.HighlightPeers && sudoku.IsPeer(i, selected) && (style == cellStyle || style == notesStyle) {
+			// Only tint the cells not already standing out.
+			style = style.Background(BlendAccent)
+		}
+		if g.selectedRow == r && g.selectedColumn == c {
+			style = style.Reverse(true)
+		}
+		ch := cellRune(cell, y%rh, x%SudokuGridColumnWidth, rh)
+		screen.SetContent(X+x, Y+y, ch, nil, style)
 	}
 
-	// One row for the numbers, second row for the borders, and we won't
-	// draw anything after the last number row.
-	for y := 0; y < (9*SudokuGridRowHeight)-1; y++ {
+	// (rh-1) rows for the numbers, one row for the borders, and we
+	// won't draw anything after the last number row.
+	for y := 0; y < (9*rh)-1; y++ {
 		switch {
 		// border between subgrid row
-		case y == (3*SudokuGridRowHeight)-1 || y == (6*SudokuGridRowHeight)-1:
+		case y == (3*rh)-1 || y == (6*rh)-1:
 			for x := 0; x < (9*SudokuGridColumnWidth)-1; x++ {
 				screen.SetContent(X+x, Y+y, hBorderHeavy, nil, heavyBorderStyle)
 			}
 			screen.SetContent(X+(SudokuGridColumnWidth*3)-1, Y+y, crossBorder, nil, heavyBorderStyle)
 			screen.SetContent(X+(SudokuGridColumnWidth*6)-1, Y+y, crossBorder, nil, heavyBorderStyle)
 		// border inside subgrid row
-		case y%SudokuGridRowHeight != 0:
+		case y%rh == rh-1:
 			runes := []rune{hBorderRt, hBorder, hBorderLt, ' '}
 			for x := 0; x < (9*SudokuGridColumnWidth)-1; x++ {
 				screen.SetContent(X+x, Y+y, runes[x%len(runes)], nil, lightBorderStyle)
@@ -313,29 +388,19 @@ func (g *SudokuGrid) Draw(screen tcell.Screen) {
 					screen.SetContent(X+x, Y+y, vBorder, nil, lightBorderStyle)
 					continue
 				}
-				r, c := y/SudokuGridRowHeight, x/SudokuGridColumnWidth
-				cell := g.GetCell(r, c)
-				if x%SudokuGridColumnWidth == 0 || x%SudokuGridColumnWidth == 2 {
-					if cell.Readonly() {
-						cell = NewReadonlySudokuCell(0)
-					} else {
-						cell = NewSudokuCell(0)
-					}
-				}
-				if g.selectedRow == r && g.selectedColumn == c {
-					drawCell(cell, func(s tcell.Style) tcell.Style {
-						return s.Reverse(true)
-					}, x, y)
-				} else {
-					drawCell(cell, func(s tcell.Style) tcell.Style {
-						return s
-					}, x, y)
-				}
+				drawCell(y/rh, x/SudokuGridColumnWidth, x, y)
 			}
 			screen.SetContent(X+(SudokuGridColumnWidth*3)-1, Y+y, vBorderHeavy, nil, heavyBorderStyle)
 			screen.SetContent(X+(SudokuGridColumnWidth*6)-1, Y+y, vBorderHeavy, nil, heavyBorderStyle)
 		}
 	}
+
+	if g.paused {
+		const text = " Paused "
+		x := (9*SudokuGridColumnWidth - 1 - len(text)) / 2
+		y := (9*rh - 1) / 2
+		tview.Print(screen, text, X+x, Y+y, len(text), tview.AlignLeft, ColorSchemes[Theme][Accent])
+	}
 }
 
 // centerCoordinates calculates and returns the (X, Y) coordinates of
@@ -346,7 +411,7 @@ func (g *SudokuGrid) centerCoordinates() (X, Y int) {
 	if width := width - (9 * SudokuGridColumnWidth) - 1; width > 0 {
 		X += width / 2
 	}
-	if height := height - (9 * SudokuGridRowHeight) - 1; height > 0 {
+	if height := height - (9 * g.rowHeight()) - 1; height > 0 {
 		Y += height / 2
 	}
 	return X, Y
@@ -388,13 +453,14 @@ func (g *SudokuGrid) cellAtCoordinate(x, y int) (r, c int) {
 	}
 	X, Y := g.centerCoordinates()
 	x, y = x-X, y-Y
-	width, height := 9*SudokuGridColumnWidth-1, 9*SudokuGridRowHeight-1
+	rh := g.rowHeight()
+	width, height := 9*SudokuGridColumnWidth-1, 9*rh-1
 	if x < 0 || y < 0 || x > width || y > height {
 		return -1, -1
 	}
-	if y%SudokuGridRowHeight == SudokuGridRowHeight-1 ||
+	if y%rh == rh-1 ||
 		x%SudokuGridColumnWidth == SudokuGridColumnWidth-1 {
 		return -1, -1
 	}
-	return y / SudokuGridRowHeight, x / SudokuGridColumnWidth
+	return y / rh, x / SudokuGridColumnWidth
 }
@@ -77,6 +77,16 @@ func InitModalStyle(m *Modal) *Modal {
 	return m
 }
 
+// InitListModalStyle initialises ListModal m with the style of Modal.
+func InitListModalStyle(m *ListModal) *ListModal {
+	m.SetBorderColor(ColorSchemes[Theme][Accent])
+	m.SetBackgroundColor(ColorSchemes[Theme]["background"])
+	m.SetTextColor(ColorSchemes[Theme]["foreground"])
+	m.SetDetailsColor(ColorSchemes[Theme]["darkerUISurface"])
+	m.SetSelectedColors(ColorSchemes[Theme]["foreground"], ColorSchemes[Theme][Accent])
+	return m
+}
+
 // viewDefaultColorScheme is used to display the colorscheme as it would
 // be used in the application for testing purposes. It returns a
 // Primitive to be set as the root of the application.